@@ -12,6 +12,7 @@ import (
 type InitGameState struct {
 	core.BaseState
 	game *game.GameData
+	opts *game.LaunchOptions
 }
 
 func (s *InitGameState) Name() string {
@@ -31,7 +32,7 @@ func (s *InitGameState) Display(ctx *core.AppContext, ui *core.UiContext) {
 }
 
 func (s *InitGameState) Handle(ctx *core.AppContext, ui *core.UiContext, input string) (core.State, error) {
-	if err := ctx.GameManager.StartGame(s.game); err != nil {
+	if err := ctx.GameManager.StartGame(s.game, s.opts); err != nil {
 		ui.DisplayError(err)
 		return ctx.GetPreviousState()
 	}
@@ -73,7 +74,7 @@ func (m *IwadSelectionMenuState) Name() string {
 	return "iwad selection menu"
 }
 
-func NewIwadSelectionMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuState {
+func NewIwadSelectionMenu(ctx *core.AppContext, ui *core.UiContext, host bool) *core.MenuState {
 	parrentState := &IwadSelectionMenuState{}
 	header := "Please select the iwad file for the game you wish to play."
 	options := make([]*core.MenuOption, 0, 10)
@@ -88,7 +89,7 @@ func NewIwadSelectionMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuSt
 		options = append(options, &core.MenuOption{
 			Id:          optNum,
 			Description: iwad,
-			NextState:   func() (core.State, error) { return &GameSelectionMenuState{iwad: iwad}, nil },
+			NextState:   func() (core.State, error) { return &GameSelectionMenuState{iwad: iwad, host: host}, nil },
 		})
 		optNum += 1
 	}
@@ -98,6 +99,7 @@ func NewIwadSelectionMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuSt
 type GameSelectionMenuState struct {
 	core.BaseState
 	iwad string
+	host bool
 }
 
 func (m *GameSelectionMenuState) Name() string {
@@ -118,7 +120,7 @@ func (m *GameSelectionMenuState) Display(ctx *core.AppContext, ui *core.UiContex
 	if len(games) == 0 {
 		ui.DisplayText("No games are currently available.\r\n\r\n")
 	}
-	ui.DisplayText("Make your choice.\r\n")
+	ui.DisplayHint("Make your choice.\r\n")
 }
 
 func (m *GameSelectionMenuState) Handle(ctx *core.AppContext, ui *core.UiContext, input string) (core.State, error) {
@@ -135,7 +137,47 @@ func (m *GameSelectionMenuState) Handle(ctx *core.AppContext, ui *core.UiContext
 	if option == 0 {
 		return ctx.GetPreviousState()
 	}
-	game := games[option-1]
-	ui.DisplayText(fmt.Sprintf("You have chosen a game: %s.\r\n", game.Name))
-	return &InitGameState{game: game}, nil
+	gameData := games[option-1]
+	ui.DisplayText(fmt.Sprintf("You have chosen a game: %s.\r\n", gameData.Name))
+	if m.host {
+		return &HostGameState{game: gameData}, nil
+	}
+	return newLaunchRequest(gameData, &game.LaunchOptions{}).start(ctx, ui)
+}
+
+// launchRequest collects the choices made by the player while preparing a game launch.
+type launchRequest struct {
+	game *game.GameData
+	opts *game.LaunchOptions
+}
+
+func newLaunchRequest(gameData *game.GameData, opts *game.LaunchOptions) *launchRequest {
+	return &launchRequest{game: gameData, opts: opts}
+}
+
+// start returns the first step of launching the game.
+func (r *launchRequest) start(ctx *core.AppContext, ui *core.UiContext) (core.State, error) {
+	presets := ctx.GameManager.PresetsForGame(r.game)
+	switch len(presets) {
+	case 0:
+		return r.afterPreset(ctx, ui)
+	case 1:
+		ui.DisplayText(fmt.Sprintf("Using launch preset %s.\r\n", presets[0]))
+		return r.choosePreset(ctx, ui, presets[0])
+	}
+	return NewPresetSelectionMenu(ctx, ui, r, presets), nil
+}
+
+// choosePreset launches the game with the preset, or without a preset if the name is empty, and remembers the choice.
+func (r *launchRequest) choosePreset(ctx *core.AppContext, ui *core.UiContext, name string) (core.State, error) {
+	r.opts.Preset = name
+	ctx.Config.Gzdoom.LastPresets[r.game.Name] = name
+	return r.afterPreset(ctx, ui)
+}
+
+func (r *launchRequest) afterPreset(ctx *core.AppContext, ui *core.UiContext) (core.State, error) {
+	if r.game.Match != nil && r.opts.Lobby == nil {
+		return NewMatchSetupMenu(ctx, ui, r.game, r.opts), nil
+	}
+	return &InitGameState{game: r.game, opts: r.opts}, nil
 }
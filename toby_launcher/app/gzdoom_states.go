@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"strings"
 	"toby_launcher/core"
-	"toby_launcher/core/game"
 )
 
 type GzdoomSettingsMenuState struct{ core.BaseState }
@@ -15,25 +14,38 @@ func (m *GzdoomSettingsMenuState) Name() string {
 
 func NewGzdoomSettingsMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuState {
 	parrentState := &GzdoomSettingsMenuState{}
-	options := []*core.MenuOption{
-		{Id: 0,
-			Description: "Back.",
-			NextState:   ctx.GetPreviousState,
-		},
-		{Id: 1,
-			Description: "Change additional GZDoom launch parametrs.",
-			NextState:   func() (core.State, error) { return &ChangeLaunchParamsState{}, nil },
-		},
-		{Id: 2,
-			Description: "Change video backend ($backend).",
-			Params:      func() map[string]any { return map[string]any{"backend": ctx.GameManager.Params.VideoBackend()} },
-			NextState:   func() (core.State, error) { return NewVideoBackendMenu(ctx, ui), nil },
-		},
-		core.NewSwitchMenuOption(3, "music", ctx.GameManager.Params.MusicPtr()),
-		core.NewSwitchMenuOption(4, "sound affects", ctx.GameManager.Params.SoundfxPtr()),
-		core.NewSwitchMenuOption(5, "debug output", &ctx.Config.Gzdoom.DebugOutput),
-		core.NewSwitchMenuOption(6, "logging", &ctx.Config.Gzdoom.Logging),
+	params := ctx.GameManager.Params
+	catalog := params.Catalog()
+	options := make([]*core.MenuOption, 0, 4+len(catalog))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	options = append(options, &core.MenuOption{
+		Id:          1,
+		Description: "Change additional GZDoom launch parametrs.",
+		NextState:   func() (core.State, error) { return &ChangeLaunchParamsState{}, nil },
+	})
+	optNum := 2
+	for _, param := range catalog {
+		if param.Spec().Group != "" {
+			continue
+		}
+		options = append(options, newParamMenuOption(ctx, ui, optNum, params, param))
+		optNum += 1
 	}
+	for _, g := range paramGroups(params) {
+		group := g
+		options = append(options, &core.MenuOption{
+			Id:          optNum,
+			Description: fmt.Sprintf("%s settings.", strings.ToUpper(group[:1])+group[1:]),
+			NextState:   func() (core.State, error) { return NewParamGroupMenu(ctx, ui, params, group), nil },
+		})
+		optNum += 1
+	}
+	options = append(options, core.NewSwitchMenuOption(optNum, "debug output", &ctx.Config.Gzdoom.DebugOutput))
+	options = append(options, core.NewSwitchMenuOption(optNum+1, "logging", &ctx.Config.Gzdoom.Logging))
 	return core.NewMenu(parrentState, options, "")
 }
 
@@ -80,48 +92,3 @@ func (s *ChangeLaunchParamsState) Handle(ctx *core.AppContext, ui *core.UiContex
 func (s *ChangeLaunchParamsState) Commands() []core.Command {
 	return []core.Command{&core.BackCommand{}}
 }
-
-type VideoBackendMenuState struct{ core.BaseState }
-
-func (s *VideoBackendMenuState) Name() string {
-	return "video backend menu"
-}
-
-func NewVideoBackendMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuState {
-	parrentState := &VideoBackendMenuState{}
-	options := make([]*core.MenuOption, 0, 5)
-	options = append(options, &core.MenuOption{
-		Id:          0,
-		Description: "Back.",
-		NextState:   ctx.GetPreviousState,
-	})
-	optNum := 1
-	for _, b := range game.VideoBackends {
-		backend := b
-		options = append(options, &core.MenuOption{
-			Id:          optNum,
-			Description: backend.String(),
-			NextState:   func() (core.State, error) { return &SelectVideoBackendState{backend: backend}, nil },
-		})
-		optNum += 1
-	}
-	return core.NewMenu(parrentState, options, "")
-}
-
-type SelectVideoBackendState struct {
-	core.BaseState
-	backend game.VidBackend
-}
-
-func (s *SelectVideoBackendState) Handle(ctx *core.AppContext, ui *core.UiContext, input string) (core.State, error) {
-	if err := ctx.GameManager.Params.SetVideoBackend(s.backend); err != nil {
-		ui.DisplayError(err)
-	} else {
-		ui.DisplayText(fmt.Sprintf("You have selected video backend: %s.\r\n", s.backend))
-	}
-	return ctx.GetStateFromDeep(2)
-}
-
-func (s *SelectVideoBackendState) RequiresInput() bool {
-	return false
-}
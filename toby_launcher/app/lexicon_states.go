@@ -0,0 +1,216 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"toby_launcher/apperrors"
+	"toby_launcher/config"
+	"toby_launcher/core"
+	"toby_launcher/core/validation"
+)
+
+type LexiconState struct{ core.BaseState }
+
+func (s *LexiconState) Name() string {
+	return "pronunciation lexicon"
+}
+
+func (s *LexiconState) Description() string {
+	return "You are in the pronunciation lexicon. Every word of the lexicon is replaced by its spoken form before a phrase is spoken, so the speech engines pronounce names such as Cacodemon correctly."
+}
+
+func (s *LexiconState) Display(ctx *core.AppContext, ui *core.UiContext) {
+	ui.DisplayText("0. Back.\r\n")
+	ui.DisplayText("1. Add a word.\r\n\r\n")
+	entries := ctx.Config.Tts.Lexicon.Entries
+	if len(entries) == 0 {
+		ui.DisplayText("The lexicon is empty.\r\n\r\n")
+	}
+	for i := range entries {
+		ui.DisplayText(fmt.Sprintf("%d. %s.\r\n", i+2, formatLexiconEntry(&entries[i])))
+	}
+	ui.DisplayHint("Make your choice.\r\n")
+}
+
+func (s *LexiconState) Handle(ctx *core.AppContext, ui *core.UiContext, input string) (core.State, error) {
+	lexicon := ctx.Config.Tts.Lexicon
+	option, err := validation.ParseIntInRange(input, 0, len(lexicon.Entries)+1)
+	if err != nil {
+		return s, err
+	}
+	switch option {
+	case 0:
+		return ctx.GetPreviousState()
+	case 1:
+		return core.NewTextInputState("word", nil, func(word string) error {
+			if lexicon.Find(word, "", "") >= 0 {
+				return apperrors.New(apperrors.Err, "The word $word is already in the lexicon.", map[string]any{"word": word})
+			}
+			lexicon.Entries = append(lexicon.Entries, config.LexiconEntry{Word: word, Spoken: word})
+			ui.TtsManager.UpdateLexicon()
+			return nil
+		}), nil
+	}
+	return NewLexiconEntryMenu(ctx, ui, option-2), nil
+}
+
+func (s *LexiconState) Commands() []core.Command {
+	return []core.Command{&core.BackCommand{}}
+}
+
+func formatLexiconEntry(entry *config.LexiconEntry) string {
+	limits := make([]string, 0, 2)
+	if entry.Engine != "" {
+		limits = append(limits, entry.Engine)
+	}
+	if entry.Language != "" {
+		limits = append(limits, entry.Language)
+	}
+	text := fmt.Sprintf("%s as %s", entry.Word, entry.Spoken)
+	if len(limits) > 0 {
+		text += fmt.Sprintf(" (%s)", strings.Join(limits, ", "))
+	}
+	return text
+}
+
+type LexiconEntryMenuState struct{ core.BaseState }
+
+func (m *LexiconEntryMenuState) Name() string {
+	return "lexicon entry menu"
+}
+
+func (m *LexiconEntryMenuState) Description() string {
+	return "You can change how the word is spoken and listen to it as it is spoken without the lexicon and with it."
+}
+
+// NewLexiconEntryMenu edits the entry with the index. The entry is looked up by the index every time,
+// as adding and deleting entries moves them in memory.
+func NewLexiconEntryMenu(ctx *core.AppContext, ui *core.UiContext, index int) *core.MenuState {
+	parrentState := &LexiconEntryMenuState{}
+	lexicon := ctx.Config.Tts.Lexicon
+	entry := func() *config.LexiconEntry { return &lexicon.Entries[index] }
+	update := func() { ui.TtsManager.UpdateLexicon() }
+	speak := func(verbatim bool) func() (core.State, error) {
+		return func() (core.State, error) {
+			phrase := ui.TtsManager.NewPhrase(entry().Word, 0, 0)
+			phrase.Verbatim = verbatim
+			ui.TtsManager.SpeakPhrase(phrase)
+			return ctx.GetCurrentState()
+		}
+	}
+	all := func(value string) string {
+		if value == "" {
+			return "all"
+		}
+		return value
+	}
+	options := []*core.MenuOption{
+		{Id: 0,
+			Description: "Back.",
+			NextState:   ctx.GetPreviousState,
+		},
+		{Id: 1,
+			Description: "Change the spoken form ($spoken).",
+			Params:      func() map[string]any { return map[string]any{"spoken": entry().Spoken} },
+			NextState: func() (core.State, error) {
+				return core.NewTextInputState("spoken form",
+					func() string { return entry().Spoken },
+					func(spoken string) error {
+						entry().Spoken = spoken
+						update()
+						return nil
+					}), nil
+			},
+		},
+		{Id: 2,
+			Description: "Speak the original.",
+			NextState:   speak(true),
+		},
+		{Id: 3,
+			Description: "Speak the corrected.",
+			NextState:   speak(false),
+		},
+		core.NewToggleMenuOption(4, "case sensitive matching",
+			func() bool { return entry().CaseSensitive },
+			func(v bool) {
+				entry().CaseSensitive = v
+				update()
+			}),
+		core.NewToggleMenuOption(5, "matching inside other words",
+			func() bool { return entry().Partial },
+			func(v bool) {
+				entry().Partial = v
+				update()
+			}),
+		{Id: 6,
+			Description: "Limit to a speech engine ($engine).",
+			Params:      func() map[string]any { return map[string]any{"engine": all(entry().Engine)} },
+			NextState:   func() (core.State, error) { return NewLexiconEngineMenu(ctx, ui, entry()), nil },
+		},
+		{Id: 7,
+			Description: "Limit to a language ($language).",
+			Params:      func() map[string]any { return map[string]any{"language": all(entry().Language)} },
+			NextState: func() (core.State, error) {
+				return core.NewTextInputState("language, such as en, or all for every language",
+					func() string { return entry().Language },
+					func(language string) error {
+						if strings.EqualFold(language, "all") {
+							language = ""
+						}
+						entry().Language = language
+						update()
+						return nil
+					}), nil
+			},
+		},
+		{Id: 8,
+			Description: "Delete the word.",
+			NextState: func() (core.State, error) {
+				word := entry().Word
+				lexicon.Entries = append(lexicon.Entries[:index], lexicon.Entries[index+1:]...)
+				update()
+				ui.DisplayText(fmt.Sprintf("The word %s deleted.\r\n", word))
+				return ctx.GetPreviousState()
+			},
+		},
+	}
+	return core.NewMenu(parrentState, options, fmt.Sprintf("The word %s.", entry().Word))
+}
+
+type LexiconEngineMenuState struct{ core.BaseState }
+
+func (m *LexiconEngineMenuState) Name() string {
+	return "lexicon engine menu"
+}
+
+// NewLexiconEngineMenu limits the entry to one of the available speech engines.
+func NewLexiconEngineMenu(ctx *core.AppContext, ui *core.UiContext, entry *config.LexiconEntry) *core.MenuState {
+	parrentState := &LexiconEngineMenuState{}
+	engines := ui.TtsManager.AvailableSynthesizers()
+	choose := func(engine string) func() (core.State, error) {
+		return func() (core.State, error) {
+			entry.Engine = engine
+			ui.TtsManager.UpdateLexicon()
+			return ctx.GetPreviousState()
+		}
+	}
+	options := make([]*core.MenuOption, 0, 2+len(engines))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	options = append(options, &core.MenuOption{
+		Id:          1,
+		Description: "All speech engines.",
+		NextState:   choose(""),
+	})
+	for i, engine := range engines {
+		options = append(options, &core.MenuOption{
+			Id:          i + 2,
+			Description: engine + ".",
+			NextState:   choose(engine),
+		})
+	}
+	return core.NewMenu(parrentState, options, "")
+}
@@ -0,0 +1,215 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"toby_launcher/config"
+	"toby_launcher/core"
+	"toby_launcher/core/game"
+)
+
+type MatchSetupMenuState struct{ core.BaseState }
+
+func (m *MatchSetupMenuState) Name() string {
+	return "match setup menu"
+}
+
+func (m *MatchSetupMenuState) Description() string {
+	return "You are setting up a deathmatch. Change the settings you need and start the match."
+}
+
+func NewMatchSetupMenu(ctx *core.AppContext, ui *core.UiContext, gameData *game.GameData, opts *game.LaunchOptions) *core.MenuState {
+	parrentState := &MatchSetupMenuState{}
+	settings := gameData.Match.Clone()
+	presets := ctx.Config.Gzdoom.MatchPresets
+	options := []*core.MenuOption{
+		{Id: 0,
+			Description: "Back.",
+			NextState:   ctx.GetPreviousState,
+		},
+		{Id: 1,
+			Description: "Start the match.",
+			NextState: func() (core.State, error) {
+				if err := game.ValidateMatch(settings); err != nil {
+					ui.DisplayError(err)
+					return ctx.GetCurrentState()
+				}
+				opts.Match = settings.Clone()
+				return &InitGameState{game: gameData, opts: opts}, nil
+			},
+		},
+		{Id: 2,
+			Description: "Change match mode ($mode).",
+			Params:      func() map[string]any { return map[string]any{"mode": settings.Mode} },
+			NextState:   func() (core.State, error) { return NewMatchModeMenu(ctx, ui, settings), nil },
+		},
+		core.NewTextMenuOption(3, "map",
+			func() string { return settings.Map },
+			func(v string) error { settings.Map = v; return nil }),
+		core.NewIntMenuOption(4, "frag limit", 0, game.MaxMatchLimit,
+			func() int { return settings.Fraglimit },
+			func(v int) error { settings.Fraglimit = v; return nil }),
+		core.NewIntMenuOption(5, "time limit in minutes", 0, game.MaxMatchLimit,
+			func() int { return settings.Timelimit },
+			func(v int) error { settings.Timelimit = v; return nil }),
+		core.NewIntMenuOption(6, "number of bots", 0, game.MaxBots,
+			func() int { return settings.Bots },
+			func(v int) error { settings.Bots = v; return nil }),
+		{Id: 7,
+			Description: "Bot options ($count enabled).",
+			Params:      func() map[string]any { return map[string]any{"count": len(settings.BotOptions)} },
+			NextState:   func() (core.State, error) { return NewBotOptionsMenu(ctx, ui, settings), nil },
+		},
+		core.NewSwitchMenuOption(8, "team play", &settings.Teamplay),
+		{Id: 9,
+			Description: "Game flags ($count enabled).",
+			Params:      func() map[string]any { return map[string]any{"count": len(settings.Flags)} },
+			NextState:   func() (core.State, error) { return NewMatchFlagsMenu(ctx, ui, settings), nil },
+		},
+		{Id: 10,
+			Description: "Save these settings as a preset.",
+			NextState: func() (core.State, error) {
+				return core.NewTextInputState("preset name", nil, func(name string) error {
+					presets[name] = settings.Clone()
+					return nil
+				}), nil
+			},
+		},
+		{Id: 11,
+			Description: "Load a preset.",
+			NextState: func() (core.State, error) {
+				return NewMatchPresetsMenu(ctx, ui, presets, func(name string) {
+					*settings = *presets[name].Clone()
+					ui.DisplayText(fmt.Sprintf("Preset %s loaded.\r\n", name))
+				}), nil
+			},
+		},
+		{Id: 12,
+			Description: "Delete a preset.",
+			NextState: func() (core.State, error) {
+				return NewMatchPresetsMenu(ctx, ui, presets, func(name string) {
+					delete(presets, name)
+					ui.DisplayText(fmt.Sprintf("Preset %s deleted.\r\n", name))
+				}), nil
+			},
+		},
+	}
+	header := fmt.Sprintf("Match setup for %s.", gameData.Name)
+	return core.NewMenu(parrentState, options, header)
+}
+
+type MatchModeMenuState struct{ core.BaseState }
+
+func (m *MatchModeMenuState) Name() string {
+	return "match mode menu"
+}
+
+func NewMatchModeMenu(ctx *core.AppContext, ui *core.UiContext, settings *config.MatchSettings) *core.MenuState {
+	parrentState := &MatchModeMenuState{}
+	options := make([]*core.MenuOption, 0, 1+len(game.MatchModes))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	for i, m := range game.MatchModes {
+		mode := m
+		options = append(options, &core.MenuOption{
+			Id:          i + 1,
+			Description: fmt.Sprintf("%s: %s.", mode.Name, mode.Description),
+			NextState: func() (core.State, error) {
+				settings.Mode = mode.Name
+				ui.DisplayText(fmt.Sprintf("You have selected match mode: %s.\r\n", mode.Name))
+				return ctx.GetPreviousState()
+			},
+		})
+	}
+	return core.NewMenu(parrentState, options, "")
+}
+
+type MatchFlagsMenuState struct{ core.BaseState }
+
+func (m *MatchFlagsMenuState) Name() string {
+	return "match flags menu"
+}
+
+func NewMatchFlagsMenu(ctx *core.AppContext, ui *core.UiContext, settings *config.MatchSettings) *core.MenuState {
+	parrentState := &MatchFlagsMenuState{}
+	options := make([]*core.MenuOption, 0, 1+len(game.DmFlags))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	for i, f := range game.DmFlags {
+		flag := f
+		options = append(options, core.NewToggleMenuOption(i+1, flag.Description,
+			func() bool { return game.MatchFlagEnabled(settings, flag.Name) },
+			func(v bool) { game.SetMatchFlag(settings, flag.Name, v) }))
+	}
+	return core.NewMenu(parrentState, options, "")
+}
+
+type BotOptionsMenuState struct{ core.BaseState }
+
+func (m *BotOptionsMenuState) Name() string {
+	return "bot options menu"
+}
+
+func (m *BotOptionsMenuState) Description() string {
+	return "The bot options apply when the match has bots."
+}
+
+func NewBotOptionsMenu(ctx *core.AppContext, ui *core.UiContext, settings *config.MatchSettings) *core.MenuState {
+	parrentState := &BotOptionsMenuState{}
+	options := make([]*core.MenuOption, 0, 1+len(game.BotOptions))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	for i, o := range game.BotOptions {
+		option := o
+		options = append(options, core.NewToggleMenuOption(i+1, option.Description,
+			func() bool { return game.BotOptionEnabled(settings, option.Name) },
+			func(v bool) { game.SetBotOption(settings, option.Name, v) }))
+	}
+	return core.NewMenu(parrentState, options, "")
+}
+
+type MatchPresetsMenuState struct{ core.BaseState }
+
+func (m *MatchPresetsMenuState) Name() string {
+	return "match presets menu"
+}
+
+func NewMatchPresetsMenu(ctx *core.AppContext, ui *core.UiContext, presets config.MatchPresets, action func(name string)) *core.MenuState {
+	parrentState := &MatchPresetsMenuState{}
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	options := make([]*core.MenuOption, 0, 1+len(names))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	for i, n := range names {
+		name := n
+		options = append(options, &core.MenuOption{
+			Id:          i + 1,
+			Description: name + ".",
+			NextState: func() (core.State, error) {
+				action(name)
+				return ctx.GetPreviousState()
+			},
+		})
+	}
+	header := ""
+	if len(names) == 0 {
+		header = "No match presets are saved."
+	}
+	return core.NewMenu(parrentState, options, header)
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"fmt"
+	"time"
+	"toby_launcher/apperrors"
+	"toby_launcher/core"
+	"toby_launcher/core/game"
+	"toby_launcher/core/lobby"
+	"toby_launcher/core/validation"
+)
+
+const lobbyDiscoveryTimeout = 2 * time.Second
+
+type NetworkGameMenuState struct{ core.BaseState }
+
+func (m *NetworkGameMenuState) Name() string {
+	return "network game menu"
+}
+
+func NewNetworkGameMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuState {
+	parrentState := &NetworkGameMenuState{}
+	options := []*core.MenuOption{
+		{Id: 0,
+			Description: "Back.",
+			NextState:   ctx.GetPreviousState,
+		},
+		{Id: 1,
+			Description: "Host a game on your network.",
+			NextState:   func() (core.State, error) { return NewIwadSelectionMenu(ctx, ui, true), nil },
+		},
+		{Id: 2,
+			Description: "Games on your network.",
+			NextState:   func() (core.State, error) { return &NetworkGamesState{}, nil },
+		},
+	}
+	return core.NewMenu(parrentState, options, "")
+}
+
+type HostGameState struct {
+	core.BaseState
+	game *game.GameData
+}
+
+func (s *HostGameState) Name() string {
+	return "host game"
+}
+
+func (s *HostGameState) Description() string {
+	return "You need to enter the number of players of the network game, including you. The game starts when all players have joined."
+}
+
+func (s *HostGameState) Display(ctx *core.AppContext, ui *core.UiContext) {
+	ui.DisplayText(fmt.Sprintf("Enter the number of players, including you (2-%d).\r\n", lobby.MaxPlayers))
+}
+
+func (s *HostGameState) Handle(ctx *core.AppContext, ui *core.UiContext, input string) (core.State, error) {
+	players, err := validation.ParseIntInRange(input, 2, lobby.MaxPlayers)
+	if err != nil {
+		return s, err
+	}
+	return newLaunchRequest(s.game, &game.LaunchOptions{Players: players}).start(ctx, ui)
+}
+
+func (s *HostGameState) Commands() []core.Command {
+	return []core.Command{&core.BackCommand{}}
+}
+
+type NetworkGamesState struct {
+	core.BaseState
+	lobbies  []*lobby.Announcement
+	searched bool
+}
+
+func (s *NetworkGamesState) Name() string {
+	return "network games"
+}
+
+func (s *NetworkGamesState) Description() string {
+	return "You are in the list of games hosted by other launchers on your network. Enter the number of the game you want to join, or press \"enter\" to search again."
+}
+
+func (s *NetworkGamesState) Display(ctx *core.AppContext, ui *core.UiContext) {
+	if !s.searched {
+		ui.DisplayText("Searching for games on your network...\r\n")
+		lobbies, err := lobby.Discover(ctx.Config.Gzdoom.LobbyAddress, lobbyDiscoveryTimeout)
+		if err != nil {
+			ui.DisplayError(apperrors.New(apperrors.Err, "Failed to search for network games: $error", map[string]any{"error": err}))
+		}
+		s.lobbies = lobbies
+		s.searched = true
+	}
+	ui.DisplayText("0. Back.\r\n\r\n")
+	if len(s.lobbies) == 0 {
+		ui.DisplayText("No games were found on your network.\r\n\r\n")
+	} else {
+		ui.DisplayText("The following games are hosted on your network:\r\n\r\n")
+	}
+	for i, a := range s.lobbies {
+		ui.DisplayText(fmt.Sprintf("%d. %s (%s), host %s, game for %d players.\r\n", i+1, a.Game, a.Iwad, a.Host, a.MaxPlayers))
+	}
+	ui.DisplayText("Press \"enter\" to search again, or make your choice.\r\n")
+}
+
+func (s *NetworkGamesState) Handle(ctx *core.AppContext, ui *core.UiContext, input string) (core.State, error) {
+	if input == "" {
+		s.searched = false
+		return s, nil
+	}
+	option, err := validation.ParseIntInRange(input, 0, len(s.lobbies))
+	if err != nil {
+		return s, err
+	}
+	if option == 0 {
+		return ctx.GetPreviousState()
+	}
+	a := s.lobbies[option-1]
+	gameData := ctx.GameManager.FindGame(a.Game)
+	if gameData == nil {
+		ui.DisplayError(apperrors.New(apperrors.Err, "The game $game is not available in your launcher.", map[string]any{"game": a.Game}))
+		return s, nil
+	}
+	ui.DisplayText(fmt.Sprintf("Joining %s at %s.\r\n", a.Game, a.Host))
+	return newLaunchRequest(gameData, &game.LaunchOptions{Lobby: a}).start(ctx, ui)
+}
+
+func (s *NetworkGamesState) Commands() []core.Command {
+	return []core.Command{&core.BackCommand{}}
+}
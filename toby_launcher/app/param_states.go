@@ -0,0 +1,345 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"toby_launcher/core"
+	"toby_launcher/core/game"
+)
+
+// newParamMenuOption creates a menu option changing a GZDoom param according to its kind.
+func newParamMenuOption(ctx *core.AppContext, ui *core.UiContext, id int, params *game.GameParams, param game.GameParam) *core.MenuOption {
+	spec := param.Spec()
+	if spec.Kind == game.BoolParamKind {
+		return core.NewToggleMenuOption(id, spec.Description,
+			func() bool { return params.Value(spec.Key) == true },
+			func(v bool) {
+				if err := params.Set(spec.Key, v); err != nil {
+					ui.DisplayError(err)
+				}
+			})
+	}
+	return &core.MenuOption{
+		Id:          id,
+		Description: "Change $name ($value).",
+		Params: func() map[string]any {
+			return map[string]any{"name": spec.Description, "value": params.Format(spec.Key)}
+		},
+		NextState: func() (core.State, error) {
+			if spec.Kind == game.EnumParamKind {
+				return NewParamChoiceMenu(ctx, ui, params, param), nil
+			}
+			if spec.ChoicesFrom != "" {
+				return NewParamSourceMenu(ctx, ui, params, param), nil
+			}
+			return &ParamValueState{params: params, param: param}, nil
+		},
+	}
+}
+
+// inheritedSource names the value a param gets when it is reset.
+func inheritedSource(params *game.GameParams) string {
+	if params.IsLayer() {
+		return "inherited"
+	}
+	return "default"
+}
+
+type ParamChoiceMenuState struct{ core.BaseState }
+
+func (m *ParamChoiceMenuState) Name() string {
+	return "param choice menu"
+}
+
+func NewParamChoiceMenu(ctx *core.AppContext, ui *core.UiContext, params *game.GameParams, param game.GameParam) *core.MenuState {
+	parrentState := &ParamChoiceMenuState{}
+	spec := param.Spec()
+	options := make([]*core.MenuOption, 0, len(spec.Choices)+2)
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	for i, c := range spec.Choices {
+		choice := c
+		options = append(options, &core.MenuOption{
+			Id:          i + 1,
+			Description: param.Format(choice.Value) + ".",
+			NextState: func() (core.State, error) {
+				if err := params.Set(spec.Key, choice.Value); err != nil {
+					ui.DisplayError(err)
+				} else {
+					ui.DisplayText(fmt.Sprintf("You have selected %s: %s.\r\n", spec.Description, param.Format(choice.Value)))
+				}
+				return ctx.GetPreviousState()
+			},
+		})
+	}
+	options = append(options, &core.MenuOption{
+		Id:          len(spec.Choices) + 1,
+		Description: "Reset to $source value ($value).",
+		Params: func() map[string]any {
+			return map[string]any{"source": inheritedSource(params), "value": params.FormatInherited(spec.Key)}
+		},
+		NextState: func() (core.State, error) {
+			params.Reset(spec.Key)
+			ui.DisplayText(fmt.Sprintf("The %s has been reset to %s value.\r\n", spec.Description, inheritedSource(params)))
+			return ctx.GetPreviousState()
+		},
+	})
+	return core.NewMenu(parrentState, options, fmt.Sprintf("Choose %s.", spec.Description))
+}
+
+// discoveredChoices returns the choices of the param found at runtime.
+func discoveredChoices(ctx *core.AppContext, spec *game.ParamSpec) []game.ParamChoice {
+	choices := make([]game.ParamChoice, 0, 10)
+	switch spec.ChoicesFrom {
+	case game.SoundfontChoices:
+		for _, path := range ctx.GameManager.Soundfonts() {
+			choices = append(choices, game.ParamChoice{Value: path, Label: filepath.Base(path)})
+		}
+	}
+	return choices
+}
+
+type ParamSourceMenuState struct{ core.BaseState }
+
+func (m *ParamSourceMenuState) Name() string {
+	return "param source menu"
+}
+
+func (m *ParamSourceMenuState) Description() string {
+	return "You can choose one of the values found on your computer or enter a value manually."
+}
+
+func NewParamSourceMenu(ctx *core.AppContext, ui *core.UiContext, params *game.GameParams, param game.GameParam) *core.MenuState {
+	parrentState := &ParamSourceMenuState{}
+	spec := param.Spec()
+	choices := discoveredChoices(ctx, spec)
+	options := make([]*core.MenuOption, 0, len(choices)+3)
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	for i, c := range choices {
+		choice := c
+		options = append(options, &core.MenuOption{
+			Id:          i + 1,
+			Description: choice.Label + ".",
+			NextState: func() (core.State, error) {
+				if err := params.Set(spec.Key, choice.Value); err != nil {
+					ui.DisplayError(err)
+				} else {
+					ui.DisplayText(fmt.Sprintf("You have selected %s: %s.\r\n", spec.Description, choice.Label))
+				}
+				return ctx.GetPreviousState()
+			},
+		})
+	}
+	options = append(options, &core.MenuOption{
+		Id:          len(choices) + 1,
+		Description: "Enter a value manually.",
+		NextState:   func() (core.State, error) { return &ParamValueState{params: params, param: param}, nil },
+	})
+	header := fmt.Sprintf("Choose %s.", spec.Description)
+	if len(choices) == 0 {
+		header = fmt.Sprintf("No values of %s were found on your computer.", spec.Description)
+	}
+	return core.NewMenu(parrentState, options, header)
+}
+
+type ParamGroupMenuState struct{ core.BaseState }
+
+func (m *ParamGroupMenuState) Name() string {
+	return "param group menu"
+}
+
+// NewParamGroupMenu creates a menu changing the params of the given group.
+func NewParamGroupMenu(ctx *core.AppContext, ui *core.UiContext, params *game.GameParams, group string) *core.MenuState {
+	parrentState := &ParamGroupMenuState{}
+	options := make([]*core.MenuOption, 0, 5)
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	optNum := 1
+	for _, param := range params.Catalog() {
+		if param.Spec().Group == group {
+			options = append(options, newParamMenuOption(ctx, ui, optNum, params, param))
+			optNum += 1
+		}
+	}
+	return core.NewMenu(parrentState, options, "")
+}
+
+// paramGroups returns the groups of the params in the order of the catalog.
+func paramGroups(params *game.GameParams) []string {
+	groups := make([]string, 0, 3)
+	seen := make(map[string]bool)
+	for _, param := range params.Catalog() {
+		group := param.Spec().Group
+		if group != "" && !seen[group] {
+			seen[group] = true
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+type ParamValueState struct {
+	core.BaseState
+	params *game.GameParams
+	param  game.GameParam
+}
+
+func (s *ParamValueState) Name() string {
+	return "change " + s.param.Spec().Description
+}
+
+func (s *ParamValueState) Description() string {
+	return fmt.Sprintf("You need to enter a new value of the param. To reset the param to its %s value, press \"enter\".", inheritedSource(s.params))
+}
+
+func (s *ParamValueState) Display(ctx *core.AppContext, ui *core.UiContext) {
+	spec := s.param.Spec()
+	switch {
+	case spec.Min != nil && spec.Max != nil:
+		ui.DisplayText(fmt.Sprintf("Enter %s (%v-%v).\r\n", spec.Description, *spec.Min, *spec.Max))
+	default:
+		ui.DisplayText(fmt.Sprintf("Enter %s.\r\n", spec.Description))
+	}
+	ui.DisplayText(fmt.Sprintf("Current value: %s.\r\n", s.params.Format(spec.Key)))
+}
+
+func (s *ParamValueState) Handle(ctx *core.AppContext, ui *core.UiContext, input string) (core.State, error) {
+	spec := s.param.Spec()
+	if input == "" {
+		s.params.Reset(spec.Key)
+		ui.DisplayText(fmt.Sprintf("The %s has been reset to %s value.\r\n", spec.Description, inheritedSource(s.params)))
+		return ctx.GetPreviousState()
+	}
+	value, err := s.param.Parse(input)
+	if err != nil {
+		return s, err
+	}
+	if err := s.params.Set(spec.Key, value); err != nil {
+		return s, err
+	}
+	ui.DisplayText(fmt.Sprintf("You have set %s: %s.\r\n", spec.Description, s.params.Format(spec.Key)))
+	return ctx.GetPreviousState()
+}
+
+func (s *ParamValueState) Commands() []core.Command {
+	return []core.Command{&core.BackCommand{}}
+}
+
+type GameParamsGamesMenuState struct{ core.BaseState }
+
+func (m *GameParamsGamesMenuState) Name() string {
+	return "game params games menu"
+}
+
+func (m *GameParamsGamesMenuState) Description() string {
+	return "You need to choose the game whose GZDoom params you want to override."
+}
+
+func NewGameParamsGamesMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuState {
+	parrentState := &GameParamsGamesMenuState{}
+	games := ctx.GameManager.AvailableGames()
+	options := make([]*core.MenuOption, 0, 1+len(games))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	for i, g := range games {
+		gameData := g
+		options = append(options, &core.MenuOption{
+			Id:          i + 1,
+			Description: gameData.Name + ".",
+			NextState:   func() (core.State, error) { return NewGameParamsMenu(ctx, ui, gameData), nil },
+		})
+	}
+	return core.NewMenu(parrentState, options, "")
+}
+
+type GameParamsMenuState struct{ core.BaseState }
+
+func (m *GameParamsMenuState) Name() string {
+	return "game params menu"
+}
+
+func (m *GameParamsMenuState) Description() string {
+	return "You are in the GZDoom params of the game. An inherited value comes from the GZDoom settings, an overridden value applies only to this game."
+}
+
+func NewGameParamsMenu(ctx *core.AppContext, ui *core.UiContext, gameData *game.GameData) *core.MenuState {
+	parrentState := &GameParamsMenuState{}
+	params := ctx.GameManager.GameParamsFor(gameData)
+	catalog := params.Catalog()
+	options := make([]*core.MenuOption, 0, 2+len(catalog))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	for i, p := range catalog {
+		param := p
+		key := param.Spec().Key
+		options = append(options, &core.MenuOption{
+			Id:          i + 1,
+			Description: "$name: $value ($source).",
+			Params: func() map[string]any {
+				source := "inherited"
+				if params.IsSet(key) {
+					source = "overridden"
+				}
+				return map[string]any{"name": param.Spec().Description, "value": params.Format(key), "source": source}
+			},
+			NextState: func() (core.State, error) { return NewGameParamMenu(ctx, ui, params, param), nil },
+		})
+	}
+	options = append(options, &core.MenuOption{
+		Id:          len(catalog) + 1,
+		Description: "Reset all params to inherited values.",
+		NextState: func() (core.State, error) {
+			for _, param := range catalog {
+				params.Reset(param.Spec().Key)
+			}
+			ui.DisplayText(fmt.Sprintf("All GZDoom params of %s have been reset to inherited values.\r\n", gameData.Name))
+			return ctx.GetPreviousState()
+		},
+	})
+	return core.NewMenu(parrentState, options, fmt.Sprintf("GZDoom params of %s.", gameData.Name))
+}
+
+type GameParamMenuState struct{ core.BaseState }
+
+func (m *GameParamMenuState) Name() string {
+	return "game param menu"
+}
+
+// NewGameParamMenu creates a menu overriding a param in the given layer or resetting it to the inherited value.
+func NewGameParamMenu(ctx *core.AppContext, ui *core.UiContext, params *game.GameParams, param game.GameParam) *core.MenuState {
+	parrentState := &GameParamMenuState{}
+	key := param.Spec().Key
+	options := []*core.MenuOption{
+		{Id: 0,
+			Description: "Back.",
+			NextState:   ctx.GetPreviousState,
+		},
+		newParamMenuOption(ctx, ui, 1, params, param),
+		{Id: 2,
+			Description: "Reset to inherited value ($value).",
+			Params:      func() map[string]any { return map[string]any{"value": params.FormatInherited(key)} },
+			NextState: func() (core.State, error) {
+				params.Reset(key)
+				ui.DisplayText(fmt.Sprintf("The %s has been reset to inherited value.\r\n", param.Spec().Description))
+				return ctx.GetPreviousState()
+			},
+		},
+	}
+	return core.NewMenu(parrentState, options, "")
+}
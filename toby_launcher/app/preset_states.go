@@ -0,0 +1,336 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"toby_launcher/apperrors"
+	"toby_launcher/config"
+	"toby_launcher/core"
+	"toby_launcher/core/validation"
+)
+
+type PresetSelectionMenuState struct{ core.BaseState }
+
+func (m *PresetSelectionMenuState) Name() string {
+	return "preset selection menu"
+}
+
+func (m *PresetSelectionMenuState) Description() string {
+	return "You need to choose the launch preset to start the game with, or start it without a preset."
+}
+
+func NewPresetSelectionMenu(ctx *core.AppContext, ui *core.UiContext, r *launchRequest, presets []string) *core.MenuState {
+	parrentState := &PresetSelectionMenuState{}
+	lastPreset, remembered := ctx.Config.Gzdoom.LastPresets[r.game.Name]
+	choose := func(name string) func() (core.State, error) {
+		return func() (core.State, error) {
+			return r.choosePreset(ctx, ui, name)
+		}
+	}
+	mark := func(name string) string {
+		if remembered && name == lastPreset {
+			return " (last used)"
+		}
+		return ""
+	}
+	options := make([]*core.MenuOption, 0, 2+len(presets))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	options = append(options, &core.MenuOption{
+		Id:          1,
+		Description: "Without a preset" + mark("") + ".",
+		NextState:   choose(""),
+	})
+	defaultId := -1
+	if remembered && lastPreset == "" {
+		defaultId = 1
+	}
+	for i, name := range presets {
+		if remembered && name == lastPreset {
+			defaultId = i + 2
+		}
+		options = append(options, &core.MenuOption{
+			Id:          i + 2,
+			Description: name + mark(name) + ".",
+			NextState:   choose(name),
+		})
+	}
+	header := fmt.Sprintf("Choose a launch preset for %s.", r.game.Name)
+	return core.NewMenu(parrentState, options, header).SetDefault(defaultId)
+}
+
+type LaunchPresetsState struct{ core.BaseState }
+
+func (s *LaunchPresetsState) Name() string {
+	return "launch presets"
+}
+
+func (s *LaunchPresetsState) Description() string {
+	return "You are in the list of launch presets. A preset overrides GZDoom params and adds launch arguments. It applies to all games or only to the games it is assigned to."
+}
+
+func (s *LaunchPresetsState) presetNames(ctx *core.AppContext) []string {
+	names := make([]string, 0, len(ctx.Config.Gzdoom.LaunchPresets))
+	for name := range ctx.Config.Gzdoom.LaunchPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *LaunchPresetsState) Display(ctx *core.AppContext, ui *core.UiContext) {
+	ui.DisplayText("0. Back.\r\n")
+	ui.DisplayText("1. Create a new preset.\r\n\r\n")
+	names := s.presetNames(ctx)
+	if len(names) == 0 {
+		ui.DisplayText("No launch presets are created.\r\n\r\n")
+	}
+	for i, name := range names {
+		ui.DisplayText(fmt.Sprintf("%d. %s (%s).\r\n", i+2, name, presetScope(ctx.Config.Gzdoom.LaunchPresets[name])))
+	}
+	ui.DisplayHint("Make your choice.\r\n")
+}
+
+func (s *LaunchPresetsState) Handle(ctx *core.AppContext, ui *core.UiContext, input string) (core.State, error) {
+	names := s.presetNames(ctx)
+	option, err := validation.ParseIntInRange(input, 0, len(names)+1)
+	if err != nil {
+		return s, err
+	}
+	switch option {
+	case 0:
+		return ctx.GetPreviousState()
+	case 1:
+		return core.NewTextInputState("preset name", nil, func(name string) error {
+			if _, exists := ctx.Config.Gzdoom.LaunchPresets[name]; exists {
+				return apperrors.New(apperrors.Err, "Preset $preset already exists.", map[string]any{"preset": name})
+			}
+			ctx.Config.Gzdoom.LaunchPresets[name] = config.NewLaunchPreset()
+			return nil
+		}), nil
+	}
+	return NewPresetEditMenu(ctx, ui, names[option-2]), nil
+}
+
+func (s *LaunchPresetsState) Commands() []core.Command {
+	return []core.Command{&core.BackCommand{}}
+}
+
+func presetScope(preset *config.LaunchPreset) string {
+	if preset.IsGlobal() {
+		return "all games"
+	}
+	return strings.Join(preset.Games, ", ")
+}
+
+type PresetEditMenuState struct{ core.BaseState }
+
+func (m *PresetEditMenuState) Name() string {
+	return "preset edit menu"
+}
+
+func NewPresetEditMenu(ctx *core.AppContext, ui *core.UiContext, name string) *core.MenuState {
+	parrentState := &PresetEditMenuState{}
+	preset := ctx.Config.Gzdoom.LaunchPresets[name]
+	options := []*core.MenuOption{
+		{Id: 0,
+			Description: "Back.",
+			NextState:   ctx.GetPreviousState,
+		},
+		{Id: 1,
+			Description: "Change GZDoom param overrides ($params).",
+			Params:      func() map[string]any { return map[string]any{"params": formatParamOverrides(preset.Params)} },
+			NextState:   func() (core.State, error) { return &PresetParamsState{preset: preset}, nil },
+		},
+		{Id: 2,
+			Description: "Change additional launch arguments ($args).",
+			Params: func() map[string]any {
+				args := strings.Join(preset.Args, "; ")
+				if args == "" {
+					args = "none"
+				}
+				return map[string]any{"args": args}
+			},
+			NextState: func() (core.State, error) { return &PresetArgsState{preset: preset}, nil },
+		},
+		{Id: 3,
+			Description: "Assign to games ($games).",
+			Params:      func() map[string]any { return map[string]any{"games": presetScope(preset)} },
+			NextState:   func() (core.State, error) { return NewPresetGamesMenu(ctx, ui, preset), nil },
+		},
+		{Id: 4,
+			Description: "Delete the preset.",
+			NextState: func() (core.State, error) {
+				delete(ctx.Config.Gzdoom.LaunchPresets, name)
+				for game, last := range ctx.Config.Gzdoom.LastPresets {
+					if last == name {
+						delete(ctx.Config.Gzdoom.LastPresets, game)
+					}
+				}
+				ui.DisplayText(fmt.Sprintf("Preset %s deleted.\r\n", name))
+				return ctx.GetPreviousState()
+			},
+		},
+	}
+	return core.NewMenu(parrentState, options, fmt.Sprintf("Launch preset %s.", name))
+}
+
+func formatParamOverrides(params config.GzdoomParams) string {
+	if len(params) == 0 {
+		return "none"
+	}
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, params[key]))
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// parseParamOverrides parses "key=value" pairs separated by semicolons.
+// Values are decoded as JSON when possible, so "true" and "2" become a bool and a number.
+func parseParamOverrides(input string) (config.GzdoomParams, error) {
+	params := make(config.GzdoomParams)
+	for _, pair := range strings.Split(input, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, rawValue, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		rawValue = strings.TrimSpace(rawValue)
+		if !found || key == "" {
+			return nil, apperrors.New(apperrors.Err, "Invalid param \"$param\", expected key=value.", map[string]any{"param": pair})
+		}
+		var value any
+		if err := json.Unmarshal([]byte(rawValue), &value); err != nil {
+			value = rawValue
+		}
+		params[key] = value
+	}
+	return params, nil
+}
+
+type PresetParamsState struct {
+	core.BaseState
+	preset *config.LaunchPreset
+}
+
+func (s *PresetParamsState) Name() string {
+	return "change preset params"
+}
+
+func (s *PresetParamsState) Description() string {
+	return "You need to specify the GZDoom params overridden by the preset as key=value pairs separated by semicolons, for example music=false. To remove all overrides, press \"enter\"."
+}
+
+func (s *PresetParamsState) Display(ctx *core.AppContext, ui *core.UiContext) {
+	ui.DisplayText("Enter the overridden GZDoom params as key=value pairs, separating them with semicolons.\r\n")
+	ui.DisplayText(fmt.Sprintf("Current value: %s.\r\n", formatParamOverrides(s.preset.Params)))
+}
+
+func (s *PresetParamsState) Handle(ctx *core.AppContext, ui *core.UiContext, input string) (core.State, error) {
+	params, err := parseParamOverrides(input)
+	if err != nil {
+		return s, err
+	}
+	if err := ctx.GameManager.Params.CheckOverrides(params); err != nil {
+		return s, err
+	}
+	s.preset.Params = params
+	ui.DisplayText(fmt.Sprintf("The following overrides are set: %s.\r\n", formatParamOverrides(params)))
+	return ctx.GetPreviousState()
+}
+
+func (s *PresetParamsState) Commands() []core.Command {
+	return []core.Command{&core.BackCommand{}}
+}
+
+type PresetArgsState struct {
+	core.BaseState
+	preset *config.LaunchPreset
+}
+
+func (s *PresetArgsState) Name() string {
+	return "change preset arguments"
+}
+
+func (s *PresetArgsState) Description() string {
+	return "You need to specify the arguments that the preset passes to GZDoom, for example -nomonsters or -fast. The separator between the arguments is a semicolon. To reset the arguments, press \"enter\"."
+}
+
+func (s *PresetArgsState) Display(ctx *core.AppContext, ui *core.UiContext) {
+	ui.DisplayText("Enter the additional launch arguments of the preset, separating them with semicolons.\r\n")
+	if len(s.preset.Args) > 0 {
+		ui.DisplayText(fmt.Sprintf("Current value: %s\r\n", strings.Join(s.preset.Args, "; ")))
+	}
+}
+
+func (s *PresetArgsState) Handle(ctx *core.AppContext, ui *core.UiContext, input string) (core.State, error) {
+	args := make([]string, 0, 5)
+	for _, a := range strings.Split(input, ";") {
+		arg := strings.TrimSpace(a)
+		if arg != "" {
+			args = append(args, arg)
+		}
+	}
+	s.preset.Args = args
+	if len(args) == 0 {
+		ui.DisplayText("Launch arguments of the preset have been reset.\r\n")
+	} else {
+		ui.DisplayText(fmt.Sprintf("The following launch arguments are set: %s.\r\n", strings.Join(args, "; ")))
+	}
+	return ctx.GetPreviousState()
+}
+
+func (s *PresetArgsState) Commands() []core.Command {
+	return []core.Command{&core.BackCommand{}}
+}
+
+type PresetGamesMenuState struct{ core.BaseState }
+
+func (m *PresetGamesMenuState) Name() string {
+	return "preset games menu"
+}
+
+func (m *PresetGamesMenuState) Description() string {
+	return "You can assign the preset to particular games. A preset that is not assigned to any game applies to all games."
+}
+
+func NewPresetGamesMenu(ctx *core.AppContext, ui *core.UiContext, preset *config.LaunchPreset) *core.MenuState {
+	parrentState := &PresetGamesMenuState{}
+	games := ctx.GameManager.AvailableGames()
+	options := make([]*core.MenuOption, 0, 1+len(games))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	for i, g := range games {
+		name := g.Name
+		options = append(options, core.NewToggleMenuOption(i+1, name,
+			func() bool { return !preset.IsGlobal() && preset.AppliesTo(name) },
+			func(v bool) {
+				games := make([]string, 0, len(preset.Games)+1)
+				for _, game := range preset.Games {
+					if game != name {
+						games = append(games, game)
+					}
+				}
+				if v {
+					games = append(games, name)
+				}
+				preset.Games = games
+			}))
+	}
+	return core.NewMenu(parrentState, options, "")
+}
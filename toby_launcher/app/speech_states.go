@@ -2,7 +2,10 @@ package app
 
 import (
 	"fmt"
+	"strings"
+	"toby_launcher/config"
 	"toby_launcher/core"
+	"toby_launcher/core/tts"
 	"toby_launcher/core/validation"
 )
 
@@ -33,6 +36,216 @@ func NewSpeechSettingsMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuS
 			},
 			NextState: func() (core.State, error) { return &SelectSpeechRateState{}, nil },
 		},
+		{Id: 3,
+			Description: "Change voice ($voice).",
+			Params: func() map[string]any {
+				voice := ctx.Config.Tts.Voice
+				if voice == "" {
+					voice = "not set"
+				}
+				return map[string]any{"voice": voice}
+			},
+			NextState: func() (core.State, error) { return newVoiceState(ctx, ui) },
+		},
+		core.NewIntMenuOption(4, "pitch", 0, tts.MaxPitch,
+			func() int { return ctx.Config.Tts.Pitch },
+			func(pitch int) error {
+				if err := ui.TtsManager.SetPitch(pitch); err != nil {
+					return err
+				}
+				ui.TtsManager.Speak(fmt.Sprintf("This is the pitch %d.", pitch))
+				return nil
+			}),
+		core.NewIntMenuOption(5, "volume", 0, tts.MaxVolume,
+			func() int { return ctx.Config.Tts.Volume },
+			func(volume int) error {
+				if err := ui.TtsManager.SetVolume(volume); err != nil {
+					return err
+				}
+				ui.TtsManager.Speak(fmt.Sprintf("This is the volume %d.", volume))
+				return nil
+			}),
+		{Id: 6,
+			Description: "Additional outputs, such as braille.",
+			NextState:   func() (core.State, error) { return NewOutputsMenu(ctx, ui), nil },
+		},
+		{Id: 7,
+			Description: "Rescan speech engines.",
+			NextState: func() (core.State, error) {
+				core.RescanSpeechEngines(ui)
+				return ctx.GetCurrentState()
+			},
+		},
+		{Id: 8,
+			Description: "Pronunciation lexicon ($count words).",
+			Params: func() map[string]any {
+				return map[string]any{"count": len(ctx.Config.Tts.Lexicon.Entries)}
+			},
+			NextState: func() (core.State, error) { return &LexiconState{}, nil },
+		},
+		{Id: 9,
+			Description: "Change self-voicing of the launcher screens ($level).",
+			Params: func() map[string]any {
+				return map[string]any{"level": ctx.Config.Tts.SelfVoicing}
+			},
+			NextState: func() (core.State, error) { return NewSelfVoicingMenu(ctx, ui), nil },
+		},
+		core.NewToggleMenuOption(10, "switching the voice by the language of the text",
+			func() bool { return ctx.Config.Tts.LanguageDetection.Enabled },
+			func(v bool) { ui.TtsManager.EnableLanguageDetection(v) }),
+		{Id: 11,
+			Description: "Text normalization, such as expanding map codes.",
+			NextState:   func() (core.State, error) { return NewNormalizationMenu(ctx, ui), nil },
+		},
+	}
+	return core.NewMenu(parrentState, options, "")
+}
+
+type NormalizationMenuState struct{ core.BaseState }
+
+func (m *NormalizationMenuState) Name() string {
+	return "text normalization menu"
+}
+
+func (m *NormalizationMenuState) Description() string {
+	return "The stages of the text normalization rewrite the text before it is spoken, for example E2M3 becomes episode 2, map 3, and 100% becomes 100 percent. Each stage can be turned off."
+}
+
+// NewNormalizationMenu turns the stages of the normalization on and off and sets the punctuation level.
+func NewNormalizationMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuState {
+	parrentState := &NormalizationMenuState{}
+	normalization := ctx.Config.Tts.Normalization
+	names := tts.NormalizerNames()
+	options := make([]*core.MenuOption, 0, 2+len(names))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	for i, n := range names {
+		name := n
+		options = append(options, core.NewToggleMenuOption(i+1, strings.ReplaceAll(name, "_", " "),
+			func() bool { return normalization.IsStageEnabled(name) },
+			func(v bool) {
+				normalization.Stages[name] = v
+				ui.TtsManager.UpdateNormalization()
+			}))
+	}
+	options = append(options, &core.MenuOption{
+		Id:          len(names) + 1,
+		Description: "Change the punctuation level ($level).",
+		Params:      func() map[string]any { return map[string]any{"level": normalization.Punctuation} },
+		NextState:   func() (core.State, error) { return NewPunctuationMenu(ctx, ui), nil },
+	})
+	return core.NewMenu(parrentState, options, "")
+}
+
+type PunctuationMenuState struct{ core.BaseState }
+
+func (m *PunctuationMenuState) Name() string {
+	return "punctuation menu"
+}
+
+func (m *PunctuationMenuState) Description() string {
+	return "The punctuation level sets which symbols are spoken by name. At the some level symbols such as & and # are spoken, the most level adds brackets, colons and dashes, and the all level adds dots, commas and the other punctuation."
+}
+
+func NewPunctuationMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuState {
+	parrentState := &PunctuationMenuState{}
+	options := make([]*core.MenuOption, 0, 1+len(config.PunctuationLevels))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	for i, l := range config.PunctuationLevels {
+		level := l
+		options = append(options, &core.MenuOption{
+			Id:          i + 1,
+			Description: level + ".",
+			NextState: func() (core.State, error) {
+				ctx.Config.Tts.Normalization.Punctuation = level
+				ui.TtsManager.UpdateNormalization()
+				msg := fmt.Sprintf("The punctuation level is %s.", level)
+				ui.DisplayText(msg + "\r\n")
+				ui.TtsManager.Speak(msg)
+				return ctx.GetPreviousState()
+			},
+		})
+	}
+	return core.NewMenu(parrentState, options, "")
+}
+
+type OutputsMenuState struct{ core.BaseState }
+
+func (m *OutputsMenuState) Name() string {
+	return "outputs menu"
+}
+
+// NewOutputsMenu lists the outputs receiving the phrases in addition to the speech synthesizer.
+func NewOutputsMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuState {
+	parrentState := &OutputsMenuState{}
+	names := tts.OutputNames()
+	options := make([]*core.MenuOption, 0, 1+len(names))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	for i, n := range names {
+		name := n
+		options = append(options, &core.MenuOption{
+			Id:          i + 1,
+			Description: "$action the $output output.",
+			Params: func() map[string]any {
+				return map[string]any{"action": !core.OptionSwitcher(ui.TtsManager.IsOutputEnabled(name)), "output": name}
+			},
+			NextState: func() (core.State, error) {
+				enabled := !ui.TtsManager.IsOutputEnabled(name)
+				if err := ui.TtsManager.EnableOutput(name, enabled); err != nil {
+					ui.DisplayError(err)
+					return ctx.GetCurrentState()
+				}
+				msg := fmt.Sprintf("The %s output is %vd.", name, core.OptionSwitcher(enabled))
+				ui.DisplayText(msg + "\r\n")
+				ui.TtsManager.Speak(msg)
+				return ctx.GetCurrentState()
+			},
+		})
+	}
+	return core.NewMenu(parrentState, options, "The outputs receive everything that is spoken.")
+}
+
+type SelfVoicingMenuState struct{ core.BaseState }
+
+func (m *SelfVoicingMenuState) Name() string {
+	return "self-voicing menu"
+}
+
+func (m *SelfVoicingMenuState) Description() string {
+	return "Self-voicing speaks the launcher screens without a screen reader. The minimal level speaks the names of the screens and errors, the normal level speaks everything displayed, and the verbose level adds hints and descriptions of the screens."
+}
+
+func NewSelfVoicingMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuState {
+	parrentState := &SelfVoicingMenuState{}
+	options := make([]*core.MenuOption, 0, 1+len(config.VerbosityLevels))
+	options = append(options, &core.MenuOption{
+		Id:          0,
+		Description: "Back.",
+		NextState:   ctx.GetPreviousState,
+	})
+	for i, l := range config.VerbosityLevels {
+		level := l
+		options = append(options, &core.MenuOption{
+			Id:          i + 1,
+			Description: level + ".",
+			NextState: func() (core.State, error) {
+				if err := core.SetSelfVoicing(ctx, ui, level); err != nil {
+					ui.DisplayError(err)
+				}
+				return ctx.GetPreviousState()
+			},
+		})
 	}
 	return core.NewMenu(parrentState, options, "")
 }
@@ -56,13 +269,13 @@ func NewSynthesizerSelectionMenu(ctx *core.AppContext, ui *core.UiContext) *core
 		synth := s
 		opt := &core.MenuOption{
 			Id:          i + 1,
-			Description: synth.Name() + ".",
+			Description: synth + ".",
 			NextState: func() (core.State, error) {
-				if err := ui.TtsManager.SetSynthesizer(synth.Name()); err != nil {
+				if err := ui.TtsManager.SetSynthesizer(synth); err != nil {
 					ui.DisplayError(err)
 					return ctx.GetCurrentState()
 				}
-				msg := fmt.Sprintf("%s selected.", synth.Name())
+				msg := fmt.Sprintf("%s selected.", synth)
 				ui.DisplayText(msg + "\r\n")
 				ui.TtsManager.Speak(msg)
 				return ctx.GetCurrentState()
@@ -85,6 +298,8 @@ func (s *SelectSpeechRateState) Description() string {
 
 func (s *SelectSpeechRateState) Display(ctx *core.AppContext, ui *core.UiContext) {
 	ui.DisplayText("Enter your desired speech rate.\r\n")
+	scale := ui.TtsManager.RateScale()
+	ui.DisplayText(fmt.Sprintf("The speech synthesizer speaks from %d to %d words per minute, the normal rate is %d.\r\n", scale.MinWpm, scale.MaxWpm, scale.NormalWpm))
 	if ctx.Config.Tts.SpeechRate > 0 {
 		ui.DisplayText(fmt.Sprintf("Current value: %d.\r\n", ctx.Config.Tts.SpeechRate))
 	}
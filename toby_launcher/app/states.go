@@ -32,12 +32,16 @@ func NewMainMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuState {
 		},
 		{Id: 1,
 			Description: "Play.",
-			NextState:   func() (core.State, error) { return NewIwadSelectionMenu(ctx, ui), nil },
+			NextState:   func() (core.State, error) { return NewIwadSelectionMenu(ctx, ui, false), nil },
 		},
 		{Id: 2,
 			Description: "Settings.",
 			NextState:   func() (core.State, error) { return NewSettingsMenu(ctx, ui), nil },
 		},
+		{Id: 3,
+			Description: "Network game.",
+			NextState:   func() (core.State, error) { return NewNetworkGameMenu(ctx, ui), nil },
+		},
 	}
 	return core.NewMenu(parentState, options, "")
 }
@@ -63,6 +67,14 @@ func NewSettingsMenu(ctx *core.AppContext, ui *core.UiContext) *core.MenuState {
 			Description: "GZDoom settings.",
 			NextState:   func() (core.State, error) { return NewGzdoomSettingsMenu(ctx, ui), nil },
 		},
+		{Id: 3,
+			Description: "Launch presets.",
+			NextState:   func() (core.State, error) { return &LaunchPresetsState{}, nil },
+		},
+		{Id: 4,
+			Description: "GZDoom params of games.",
+			NextState:   func() (core.State, error) { return NewGameParamsGamesMenu(ctx, ui), nil },
+		},
 	}
 	return core.NewMenu(parrentState, options, "")
 }
@@ -0,0 +1,224 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"toby_launcher/core"
+	"toby_launcher/core/tts"
+	"toby_launcher/core/validation"
+)
+
+// voicePreviewText is spoken to preview a voice before it is selected.
+const voicePreviewText = "Hello! This is how I sound."
+
+// voiceLanguages returns the languages of the voices in alphabetical order.
+func voiceLanguages(voices []tts.Voice) []string {
+	seen := make(map[string]bool)
+	languages := make([]string, 0, len(voices))
+	for _, v := range voices {
+		if !seen[v.Language] {
+			seen[v.Language] = true
+			languages = append(languages, v.Language)
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+func filterVoices(voices []tts.Voice, match func(v tts.Voice) bool) []tts.Voice {
+	filtered := make([]tts.Voice, 0, len(voices))
+	for _, v := range voices {
+		if match(v) {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
+}
+
+// voicesMatching returns the voices whose name, id or language contains the text.
+func voicesMatching(voices []tts.Voice, text string) []tts.Voice {
+	text = strings.ToLower(text)
+	return filterVoices(voices, func(v tts.Voice) bool {
+		return strings.Contains(strings.ToLower(v.Name), text) ||
+			strings.Contains(strings.ToLower(v.Id), text) ||
+			strings.Contains(strings.ToLower(v.Language), text)
+	})
+}
+
+func formatVoice(v tts.Voice) string {
+	if v.Gender == "" {
+		return fmt.Sprintf("%s (%s)", v.Name, v.Language)
+	}
+	return fmt.Sprintf("%s (%s, %s)", v.Name, v.Language, v.Gender)
+}
+
+// newVoiceState returns the voice picker, or a text input if the synthesizer does not list its voices.
+func newVoiceState(ctx *core.AppContext, ui *core.UiContext) (core.State, error) {
+	voices, err := ui.TtsManager.Voices()
+	if err != nil {
+		ui.DisplayError(err)
+	}
+	if len(voices) == 0 {
+		return core.NewTextInputState("voice", func() string { return ctx.Config.Tts.Voice }, func(voice string) error {
+			if err := ui.TtsManager.SetVoice(voice); err != nil {
+				return err
+			}
+			ui.TtsManager.Speak(voicePreviewText)
+			return nil
+		}), nil
+	}
+	return &VoiceLanguagesState{voices: voices}, nil
+}
+
+type VoiceLanguagesState struct {
+	core.BaseState
+	voices []tts.Voice
+}
+
+func (s *VoiceLanguagesState) Name() string {
+	return "voice languages"
+}
+
+func (s *VoiceLanguagesState) Description() string {
+	return "You are in the list of languages of the voices. Enter the number of a language to see its voices, or type a part of the voice name to find it."
+}
+
+func (s *VoiceLanguagesState) Display(ctx *core.AppContext, ui *core.UiContext) {
+	ui.DisplayText("0. Back.\r\n\r\n")
+	for i, language := range voiceLanguages(s.voices) {
+		count := len(filterVoices(s.voices, func(v tts.Voice) bool { return v.Language == language }))
+		ui.DisplayText(fmt.Sprintf("%d. %s (%d voices).\r\n", i+1, language, count))
+	}
+	ui.DisplayText("Choose a language or type a part of the voice name.\r\n")
+}
+
+func (s *VoiceLanguagesState) Handle(ctx *core.AppContext, ui *core.UiContext, input string) (core.State, error) {
+	languages := voiceLanguages(s.voices)
+	if _, err := validation.ParseInt(input); err != nil {
+		voices := voicesMatching(s.voices, input)
+		if len(voices) == 0 {
+			ui.DisplayText(fmt.Sprintf("No voices match \"%s\".\r\n", input))
+			return s, nil
+		}
+		return &VoiceListState{voices: voices}, nil
+	}
+	option, err := validation.ParseIntInRange(input, 0, len(languages))
+	if err != nil {
+		return s, err
+	}
+	if option == 0 {
+		return ctx.GetPreviousState()
+	}
+	language := languages[option-1]
+	return &VoiceListState{voices: filterVoices(s.voices, func(v tts.Voice) bool { return v.Language == language })}, nil
+}
+
+func (s *VoiceLanguagesState) Commands() []core.Command {
+	return []core.Command{&core.BackCommand{}}
+}
+
+type VoiceListState struct {
+	core.BaseState
+	voices []tts.Voice
+	filter string
+}
+
+func (s *VoiceListState) Name() string {
+	return "voice list"
+}
+
+func (s *VoiceListState) Description() string {
+	return "You are in the list of voices. Enter the number of a voice to preview it, type a part of the voice name to narrow the list, or press \"enter\" to show all voices again."
+}
+
+func (s *VoiceListState) shown() []tts.Voice {
+	if s.filter == "" {
+		return s.voices
+	}
+	return voicesMatching(s.voices, s.filter)
+}
+
+func (s *VoiceListState) Display(ctx *core.AppContext, ui *core.UiContext) {
+	ui.DisplayText("0. Back.\r\n\r\n")
+	for i, v := range s.shown() {
+		ui.DisplayText(fmt.Sprintf("%d. %s.\r\n", i+1, formatVoice(v)))
+	}
+	ui.DisplayText("Choose a voice to preview it, or type a part of its name.\r\n")
+}
+
+func (s *VoiceListState) Handle(ctx *core.AppContext, ui *core.UiContext, input string) (core.State, error) {
+	if input == "" {
+		s.filter = ""
+		return s, nil
+	}
+	if _, err := validation.ParseInt(input); err != nil {
+		if len(voicesMatching(s.voices, input)) == 0 {
+			ui.DisplayText(fmt.Sprintf("No voices match \"%s\".\r\n", input))
+			return s, nil
+		}
+		s.filter = input
+		return s, nil
+	}
+	voices := s.shown()
+	option, err := validation.ParseIntInRange(input, 0, len(voices))
+	if err != nil {
+		return s, err
+	}
+	if option == 0 {
+		return ctx.GetPreviousState()
+	}
+	voice := voices[option-1]
+	previewVoice(ui, voice)
+	return NewVoicePreviewMenu(ctx, ui, voice), nil
+}
+
+func (s *VoiceListState) Commands() []core.Command {
+	return []core.Command{&core.BackCommand{}}
+}
+
+type VoicePreviewMenuState struct{ core.BaseState }
+
+func (m *VoicePreviewMenuState) Name() string {
+	return "voice preview menu"
+}
+
+// previewVoice speaks the preview text with the voice without changing the selected voice.
+func previewVoice(ui *core.UiContext, voice tts.Voice) {
+	phrase := ui.TtsManager.NewPhrase(voicePreviewText, 0, 0)
+	phrase.Voice = voice.Id
+	phrase.Priority = tts.UiPriority
+	ui.TtsManager.SpeakPhrase(phrase)
+}
+
+func NewVoicePreviewMenu(ctx *core.AppContext, ui *core.UiContext, voice tts.Voice) *core.MenuState {
+	parrentState := &VoicePreviewMenuState{}
+	options := []*core.MenuOption{
+		{Id: 0,
+			Description: "Back.",
+			NextState:   ctx.GetPreviousState,
+		},
+		{Id: 1,
+			Description: "Select this voice.",
+			NextState: func() (core.State, error) {
+				if err := ui.TtsManager.SetVoice(voice.Id); err != nil {
+					ui.DisplayError(err)
+					return ctx.GetPreviousState()
+				}
+				msg := fmt.Sprintf("You have selected voice: %s.", voice.Name)
+				ui.DisplayText(msg + "\r\n")
+				ui.TtsManager.Speak(msg)
+				// Return to the speech settings over the voice list and the list of languages.
+				return ctx.GetStateFromDeep(3)
+			},
+		},
+		{Id: 2,
+			Description: "Listen again.",
+			NextState: func() (core.State, error) {
+				previewVoice(ui, voice)
+				return ctx.GetCurrentState()
+			},
+		},
+	}
+	return core.NewMenu(parrentState, options, fmt.Sprintf("Voice %s.", formatVoice(voice)))
+}
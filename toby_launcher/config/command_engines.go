@@ -0,0 +1,78 @@
+package config
+
+import (
+	"strings"
+	"toby_launcher/apperrors"
+)
+
+const (
+	// ArgumentInput passes the text to a command engine in its arguments.
+	ArgumentInput = "argument"
+	// StdinInput writes the text to the standard input of a command engine.
+	StdinInput = "stdin"
+)
+
+// CommandEngine is a speech engine running a user-defined command for every phrase.
+// The arguments of Command and Player may contain the placeholders $text, $rate, $voice and $file.
+// If Player is set, Command writes a WAV file to $file, which is then played by Player.
+type CommandEngine struct {
+	Name    string   `json:"name"`
+	Command []string `json:"command"`
+	// Input is ArgumentInput or StdinInput, ArgumentInput by default.
+	Input  string   `json:"input,omitempty"`
+	Player []string `json:"player,omitempty"`
+	// Rate is the speech rate passed when no rate is set, in words per minute.
+	Rate int `json:"rate,omitempty"`
+	// Voice is the voice passed when no voice is set.
+	Voice string `json:"voice,omitempty"`
+}
+
+// UsesPlaceholder reports whether the command or the player contains the placeholder.
+func (e *CommandEngine) UsesPlaceholder(placeholder string) bool {
+	for _, args := range [][]string{e.Command, e.Player} {
+		for _, arg := range args {
+			if strings.Contains(arg, placeholder) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *CommandEngine) validate() error {
+	if e.Name == "" {
+		return apperrors.New(apperrors.Err, "command engine name is missing", nil)
+	}
+	if len(e.Command) == 0 {
+		return apperrors.New(apperrors.Err, "command of engine \"$name\" is missing", map[string]any{"name": e.Name})
+	}
+	if e.Input != "" && e.Input != ArgumentInput && e.Input != StdinInput {
+		return apperrors.New(apperrors.Err, "engine \"$name\" has unknown input \"$input\", expected \"$argument\" or \"$stdin\"", map[string]any{
+			"name":     e.Name,
+			"input":    e.Input,
+			"argument": ArgumentInput,
+			"stdin":    StdinInput,
+		})
+	}
+	if len(e.Player) > 0 && !e.UsesPlaceholder("$file") {
+		return apperrors.New(apperrors.Err, "engine \"$name\" has a player but does not use $file", map[string]any{"name": e.Name})
+	}
+	if e.Rate < 0 || e.Rate > 1000 {
+		return apperrors.New(apperrors.Err, "rate of engine \"$name\" must be from 0 to 1000", map[string]any{"name": e.Name})
+	}
+	return nil
+}
+
+func validateCommandEngines(engines []CommandEngine) error {
+	names := make(map[string]bool, len(engines))
+	for i := range engines {
+		if err := engines[i].validate(); err != nil {
+			return err
+		}
+		if names[engines[i].Name] {
+			return apperrors.New(apperrors.Err, "command engine \"$name\" is defined more than once", map[string]any{"name": engines[i].Name})
+		}
+		names[engines[i].Name] = true
+	}
+	return nil
+}
@@ -29,6 +29,8 @@ type Config struct {
 	Paths  *PathConfig
 	Tts    *TtsConfig
 	Gzdoom *GzdoomConfig
+	// lexiconIsBroken keeps the lexicon file that failed to load from being overwritten.
+	lexiconIsBroken bool
 }
 
 func NewConfig() (*Config, error) {
@@ -38,7 +40,7 @@ func NewConfig() (*Config, error) {
 	}
 	return &Config{
 		Paths:  pathConfig,
-		Tts:    &TtsConfig{},
+		Tts:    NewTtsConfig(),
 		Gzdoom: NewGzdoomConfig(),
 	}, nil
 }
@@ -72,6 +74,19 @@ func (c *Config) Load(filePath string) error {
 	return nil
 }
 
+// LoadLexicon loads the pronunciation dictionary of the user. A missing file leaves the lexicon empty.
+func (c *Config) LoadLexicon() error {
+	path := c.Paths.LexiconPath()
+	if !file_utils.Exists(path) {
+		return nil
+	}
+	if err := c.Tts.Lexicon.Load(path); err != nil {
+		c.lexiconIsBroken = true
+		return err
+	}
+	return nil
+}
+
 func (c *Config) Save() error {
 	var cfgData configData
 	cfgData.Tts = c.Tts.save()
@@ -79,5 +94,10 @@ func (c *Config) Save() error {
 	if err := file_utils.SaveData(c.Paths.ConfigFilePath(), cfgData); err != nil {
 		return err
 	}
+	if !c.lexiconIsBroken {
+		if err := c.Tts.Lexicon.Save(c.Paths.LexiconPath()); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -7,10 +7,15 @@ import (
 type GzdoomParams map[string]any
 
 type gzdoomConfigData struct {
-	Params           GzdoomParams `json:"params"`
-	AdditionalParams []string     `json:"additional_params"`
-	DebugOutput      bool         `json:"debug_output"`
-	Logging          bool         `json:"logging"`
+	Params           GzdoomParams            `json:"params"`
+	AdditionalParams []string                `json:"additional_params"`
+	DebugOutput      bool                    `json:"debug_output"`
+	Logging          bool                    `json:"logging"`
+	MatchPresets     MatchPresets            `json:"match_presets,omitempty"`
+	LaunchPresets    LaunchPresets           `json:"launch_presets,omitempty"`
+	LastPresets      map[string]string       `json:"last_presets,omitempty"`
+	PerGameParams    map[string]GzdoomParams `json:"game_params,omitempty"`
+	LobbyAddress     string                  `json:"lobby_address,omitempty"`
 }
 
 func (d *gzdoomConfigData) validate() error {
@@ -34,12 +39,25 @@ type GzdoomConfig struct {
 	AdditionalLaunchParams []string
 	DebugOutput            bool
 	Logging                bool
+	MatchPresets           MatchPresets
+	LaunchPresets          LaunchPresets
+	// LastPresets maps a game name to the launch preset chosen the last time, empty for none.
+	LastPresets map[string]string
+	// PerGameParams maps a game name to the GZDoom params overridden for that game.
+	PerGameParams map[string]GzdoomParams
+	// LobbyAddress is the address hosted network games are announced to and searched on, "host" or "host:port".
+	// Empty for the broadcasts on the local network.
+	LobbyAddress string
 }
 
 func NewGzdoomConfig() *GzdoomConfig {
 	return &GzdoomConfig{
 		GameParams:             make(map[string]any, 5),
 		AdditionalLaunchParams: make([]string, 0, 5),
+		MatchPresets:           make(MatchPresets),
+		LaunchPresets:          make(LaunchPresets),
+		LastPresets:            make(map[string]string),
+		PerGameParams:          make(map[string]GzdoomParams),
 	}
 }
 
@@ -51,6 +69,19 @@ func (c *GzdoomConfig) load(data *gzdoomConfigData) error {
 	c.AdditionalLaunchParams = data.AdditionalParams
 	c.Logging = data.Logging
 	c.DebugOutput = data.DebugOutput
+	if data.MatchPresets != nil {
+		c.MatchPresets = data.MatchPresets
+	}
+	if data.LaunchPresets != nil {
+		c.LaunchPresets = data.LaunchPresets
+	}
+	if data.LastPresets != nil {
+		c.LastPresets = data.LastPresets
+	}
+	if data.PerGameParams != nil {
+		c.PerGameParams = data.PerGameParams
+	}
+	c.LobbyAddress = data.LobbyAddress
 	return nil
 }
 
@@ -58,6 +89,16 @@ func (c *GzdoomConfig) save() *gzdoomConfigData {
 	data := &gzdoomConfigData{
 		Params:           c.GameParams,
 		AdditionalParams: c.AdditionalLaunchParams,
+		MatchPresets:     c.MatchPresets,
+		LaunchPresets:    c.LaunchPresets,
+		LastPresets:      c.LastPresets,
+		LobbyAddress:     c.LobbyAddress,
+		PerGameParams:    make(map[string]GzdoomParams, len(c.PerGameParams)),
+	}
+	for game, params := range c.PerGameParams {
+		if len(params) > 0 {
+			data.PerGameParams[game] = params
+		}
 	}
 	if c.DebugOutput {
 		data.DebugOutput = c.DebugOutput
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"regexp"
+	"toby_launcher/apperrors"
+	"unicode"
+)
+
+// LanguageRule recognizes the text of a language either by its Unicode script, such as "Cyrillic",
+// or by a regular expression, such as the names of the maps of a mod.
+type LanguageRule struct {
+	Script   string `json:"script,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+	Language string `json:"language"`
+}
+
+// DefaultLanguageRules tell Russian from English.
+var DefaultLanguageRules = []LanguageRule{
+	{Script: "Cyrillic", Language: "ru"},
+	{Script: "Latin", Language: "en"},
+}
+
+// LanguageDetection configures the automatic switching of voices by the language of the text.
+// Voices maps a language to the voices of the speech engines, e.g. {"ru": {"espeak": "ru"}}.
+// Text in a language without a voice for the current engine is spoken by the selected voice.
+type LanguageDetection struct {
+	Enabled bool                         `json:"enabled"`
+	Rules   []LanguageRule               `json:"rules,omitempty"`
+	Voices  map[string]map[string]string `json:"voices,omitempty"`
+}
+
+func NewLanguageDetection() *LanguageDetection {
+	return &LanguageDetection{
+		Rules:  DefaultLanguageRules,
+		Voices: make(map[string]map[string]string),
+	}
+}
+
+// Voice returns the voice of the language for the speech engine, or an empty string if there is none.
+func (d *LanguageDetection) Voice(language, engine string) string {
+	return d.Voices[language][engine]
+}
+
+func (r *LanguageRule) validate() error {
+	if r.Language == "" {
+		return apperrors.New(apperrors.Err, "language of a rule is missing", nil)
+	}
+	if (r.Script == "") == (r.Pattern == "") {
+		return apperrors.New(apperrors.Err, "rule of language \"$language\" needs either a script or a pattern", map[string]any{"language": r.Language})
+	}
+	if r.Script != "" {
+		if _, exists := unicode.Scripts[r.Script]; !exists {
+			return apperrors.New(apperrors.Err, "rule of language \"$language\" has unknown script \"$script\"", map[string]any{
+				"language": r.Language,
+				"script":   r.Script,
+			})
+		}
+	}
+	if r.Pattern != "" {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return apperrors.New(apperrors.Err, "rule of language \"$language\" has invalid pattern: $error", map[string]any{
+				"language": r.Language,
+				"error":    err,
+			})
+		}
+	}
+	return nil
+}
+
+func (d *LanguageDetection) validate() error {
+	for i := range d.Rules {
+		if err := d.Rules[i].validate(); err != nil {
+			return err
+		}
+	}
+	for language, voices := range d.Voices {
+		for engine, voice := range voices {
+			if voice == "" {
+				return apperrors.New(apperrors.Err, "voice of language \"$language\" for engine \"$engine\" is empty", map[string]any{
+					"language": language,
+					"engine":   engine,
+				})
+			}
+		}
+	}
+	return nil
+}
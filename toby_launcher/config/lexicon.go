@@ -0,0 +1,72 @@
+package config
+
+import (
+	"toby_launcher/apperrors"
+	"toby_launcher/utils/file_utils"
+)
+
+// LexiconEntry replaces a word with its spoken form in every phrase before it is spoken.
+type LexiconEntry struct {
+	Word   string `json:"word"`
+	Spoken string `json:"spoken"`
+	// CaseSensitive matches the word only if its case is the same.
+	CaseSensitive bool `json:"case_sensitive,omitempty"`
+	// Partial matches the word inside other words too, otherwise only whole words are matched.
+	Partial bool `json:"partial,omitempty"`
+	// Engine limits the entry to the speech engine with this name.
+	Engine string `json:"engine,omitempty"`
+	// Language limits the entry to the voices of this language, such as "en" or "en-us".
+	Language string `json:"language,omitempty"`
+}
+
+func (e *LexiconEntry) validate() error {
+	if e.Word == "" {
+		return apperrors.New(apperrors.Err, "lexicon entry word is missing", nil)
+	}
+	return nil
+}
+
+// Lexicon is the pronunciation dictionary of the user, kept in its own file.
+type Lexicon struct {
+	Entries []LexiconEntry `json:"entries"`
+}
+
+func NewLexicon() *Lexicon {
+	return &Lexicon{Entries: make([]LexiconEntry, 0)}
+}
+
+func (l *Lexicon) Load(filePath string) error {
+	var data Lexicon
+	if err := file_utils.LoadData(filePath, &data); err != nil {
+		return apperrors.New(apperrors.Err, "Error parsing file $file: $error", map[string]any{
+			"file":  filePath,
+			"error": err,
+		})
+	}
+	for i := range data.Entries {
+		if err := data.Entries[i].validate(); err != nil {
+			return apperrors.New(apperrors.Err, "Error in lexicon file $file: $error", map[string]any{
+				"file":  filePath,
+				"error": err,
+			})
+		}
+	}
+	if data.Entries != nil {
+		l.Entries = data.Entries
+	}
+	return nil
+}
+
+func (l *Lexicon) Save(filePath string) error {
+	return file_utils.SaveData(filePath, l)
+}
+
+// Find returns the index of the entry of the word for the engine and the language, or -1.
+func (l *Lexicon) Find(word, engine, language string) int {
+	for i, entry := range l.Entries {
+		if entry.Word == word && entry.Engine == engine && entry.Language == language {
+			return i
+		}
+	}
+	return -1
+}
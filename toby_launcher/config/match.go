@@ -0,0 +1,23 @@
+package config
+
+// MatchSettings describes the setup of a deathmatch game.
+type MatchSettings struct {
+	Mode      string `json:"mode"`
+	Map       string `json:"map"`
+	Fraglimit int    `json:"fraglimit"`
+	Timelimit int    `json:"timelimit"`
+	Bots      int    `json:"bots"`
+	// BotOptions are the names of the bot options turned on.
+	BotOptions []string `json:"bot_options,omitempty"`
+	Teamplay   bool     `json:"teamplay"`
+	Flags      []string `json:"flags"`
+}
+
+func (s *MatchSettings) Clone() *MatchSettings {
+	clone := *s
+	clone.Flags = append([]string{}, s.Flags...)
+	clone.BotOptions = append([]string{}, s.BotOptions...)
+	return &clone
+}
+
+type MatchPresets map[string]*MatchSettings
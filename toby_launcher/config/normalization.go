@@ -0,0 +1,82 @@
+package config
+
+import (
+	"toby_launcher/apperrors"
+)
+
+// PunctuationLevels are the levels of the punctuation spoken by name, from the quietest one.
+var PunctuationLevels = []string{"none", "some", "most", "all"}
+
+// DefaultPunctuation speaks the symbols engines tend to skip, such as "&" and "#".
+const DefaultPunctuation = "some"
+
+// DefaultAbbreviations are the abbreviations of game messages the engines read badly.
+var DefaultAbbreviations = map[string]string{
+	"HP":  "health",
+	"FPS": "frames per second",
+	"DM":  "deathmatch",
+	"CTF": "capture the flag",
+}
+
+// Normalization configures the stages rewriting the text before it is spoken.
+type Normalization struct {
+	// Stages turns the stages on and off by their names, the stages missing from it are on.
+	Stages map[string]bool `json:"stages,omitempty"`
+	// Punctuation is one of PunctuationLevels.
+	Punctuation string `json:"punctuation,omitempty"`
+	// Abbreviations map the abbreviations to their spoken forms, matched as whole words with the case.
+	Abbreviations map[string]string `json:"abbreviations,omitempty"`
+}
+
+func NewNormalization() *Normalization {
+	abbreviations := make(map[string]string, len(DefaultAbbreviations))
+	for abbreviation, spoken := range DefaultAbbreviations {
+		abbreviations[abbreviation] = spoken
+	}
+	return &Normalization{
+		Stages:        make(map[string]bool),
+		Punctuation:   DefaultPunctuation,
+		Abbreviations: abbreviations,
+	}
+}
+
+// IsStageEnabled reports whether the stage with the name is on.
+func (n *Normalization) IsStageEnabled(name string) bool {
+	enabled, exists := n.Stages[name]
+	return !exists || enabled
+}
+
+// PunctuationLevel returns the index of the punctuation level in PunctuationLevels, or -1 if it is unknown.
+func PunctuationLevel(name string) int {
+	for i, level := range PunctuationLevels {
+		if level == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (n *Normalization) validate() error {
+	if n.Punctuation != "" && PunctuationLevel(n.Punctuation) < 0 {
+		return apperrors.New(apperrors.Err, "unknown punctuation level \"$level\"", map[string]any{"level": n.Punctuation})
+	}
+	for abbreviation := range n.Abbreviations {
+		if abbreviation == "" {
+			return apperrors.New(apperrors.Err, "abbreviation is empty", nil)
+		}
+	}
+	return nil
+}
+
+// isDefault reports whether the normalization has the default settings, so it is not saved.
+func (n *Normalization) isDefault() bool {
+	if len(n.Stages) > 0 || n.Punctuation != DefaultPunctuation || len(n.Abbreviations) != len(DefaultAbbreviations) {
+		return false
+	}
+	for abbreviation, spoken := range n.Abbreviations {
+		if DefaultAbbreviations[abbreviation] != spoken {
+			return false
+		}
+	}
+	return true
+}
@@ -73,14 +73,29 @@ func (pc *PathConfig) GzdoomLogFilePath() string {
 	return filepath.Join(pc.BaseDir, logFile)
 }
 
+// LaunchScriptPath returns the path to the console script generated for each game launch.
+func (pc *PathConfig) LaunchScriptPath() string {
+	return filepath.Join(pc.BaseDir, "launch.cfg")
+}
+
 func (pc *PathConfig) GamesPath() string {
 	return filepath.Join(pc.BaseDir, "games.json")
 }
 
+// GameParamsPath returns the path to the catalog of GZDoom params.
+func (pc *PathConfig) GameParamsPath() string {
+	return filepath.Join(pc.BaseDir, "game_params.json")
+}
+
 func (pc *PathConfig) TextRulesPath() string {
 	return filepath.Join(pc.BaseDir, "text_rules.json")
 }
 
+// LexiconPath returns the path to the pronunciation dictionary of the user.
+func (pc *PathConfig) LexiconPath() string {
+	return filepath.Join(pc.BaseDir, "lexicon.json")
+}
+
 func (pc *PathConfig) GameFilePath(file string) string {
 	return filepath.Join(pc.BaseDir, "files", file)
 }
@@ -119,6 +134,18 @@ func (pc *PathConfig) GzdoomPath() (string, error) {
 	return "", fmt.Errorf("gzdoom executable not found in portable, system, or PATH")
 }
 
+// SoundfontDirs returns the directories searched for MIDI soundfonts.
+func (pc *PathConfig) SoundfontDirs() []string {
+	dirs := []string{pc.FilesDir, filepath.Join(pc.BaseDir, "soundfonts")}
+	if gzdoomPath, err := pc.GzdoomPath(); err == nil {
+		dirs = append(dirs, filepath.Join(filepath.Dir(gzdoomPath), "soundfonts"))
+	}
+	if runtime.GOOS == "linux" {
+		dirs = append(dirs, "/usr/share/sounds/sf2", "/usr/share/soundfonts")
+	}
+	return dirs
+}
+
 func OsConfigDir(platform string) (string, error) {
 	switch platform {
 	case "linux":
@@ -0,0 +1,35 @@
+package config
+
+// LaunchPreset is a named bundle of GZDoom param overrides and extra launch arguments.
+type LaunchPreset struct {
+	Params GzdoomParams `json:"params"`
+	Args   []string     `json:"args"`
+	// Games lists the games the preset is assigned to, an empty list assigns it to all games.
+	Games []string `json:"games"`
+}
+
+func NewLaunchPreset() *LaunchPreset {
+	return &LaunchPreset{
+		Params: make(GzdoomParams),
+		Args:   make([]string, 0),
+		Games:  make([]string, 0),
+	}
+}
+
+func (p *LaunchPreset) IsGlobal() bool {
+	return len(p.Games) == 0
+}
+
+func (p *LaunchPreset) AppliesTo(game string) bool {
+	if p.IsGlobal() {
+		return true
+	}
+	for _, g := range p.Games {
+		if g == game {
+			return true
+		}
+	}
+	return false
+}
+
+type LaunchPresets map[string]*LaunchPreset
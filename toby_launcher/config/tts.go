@@ -1,13 +1,64 @@
 package config
 
 import (
+	"net"
 	"toby_launcher/apperrors"
 	"toby_launcher/core/validation"
 )
 
+// DefaultFestivalAddress is the address of a Festival server started with "festival --server".
+const DefaultFestivalAddress = "localhost:1314"
+
+// DefaultMaxQueueLength is the number of phrases waiting to be spoken, above which phrases are dropped.
+const DefaultMaxQueueLength = 20
+
+// TextOutput configures the synthesizers writing phrases instead of speaking them.
+type TextOutput struct {
+	// ConsolePrefix is printed before every phrase written to the console.
+	ConsolePrefix string `json:"console_prefix,omitempty"`
+	// File is the file or the named pipe the phrases are appended to.
+	File string `json:"file,omitempty"`
+	// Command is the command started once, the phrases are written to its standard input.
+	Command []string `json:"command,omitempty"`
+}
+
+// DefaultBrailleHost is the host of the local BRLTTY server.
+const DefaultBrailleHost = ":0"
+
+// DefaultBrailleKeyFile is the file of the key authenticating the clients of BRLTTY.
+const DefaultBrailleKeyFile = "/etc/brlapi.key"
+
+// BrailleOutput configures the connection to BRLTTY. Host has the form of the BRLAPI_HOST
+// environment variable: ":N" is the local socket N, "host:N" is the TCP port 4101+N of the host.
+type BrailleOutput struct {
+	Host    string `json:"host,omitempty"`
+	KeyFile string `json:"key_file,omitempty"`
+}
+
+// VerbosityLevels are the levels of the self-voicing user interface from the quietest one.
+var VerbosityLevels = []string{"off", "minimal", "normal", "verbose"}
+
+// DefaultVerbosity leaves the speech of the user interface to the screen reader.
+const DefaultVerbosity = "off"
+
 type ttsConfigData struct {
-	SpeechEngine *string `json:"speech_engine"`
-	Rate         *int    `json:"rate"`
+	SpeechEngine    *string         `json:"speech_engine"`
+	Rate            *int            `json:"rate"`
+	MaxQueueLength  *int            `json:"max_queue_length,omitempty"`
+	Voice           string          `json:"voice,omitempty"`
+	Pitch           int             `json:"pitch,omitempty"`
+	Volume          int             `json:"volume,omitempty"`
+	CommandEngines  []CommandEngine `json:"command_engines,omitempty"`
+	FestivalAddress string          `json:"festival_address,omitempty"`
+	TextOutput      *TextOutput     `json:"text_output,omitempty"`
+	Braille         *BrailleOutput  `json:"braille,omitempty"`
+	Outputs         []string        `json:"outputs,omitempty"`
+	EngineOrder     []string        `json:"engine_order,omitempty"`
+	SelfVoicing     string          `json:"self_voicing,omitempty"`
+	// LanguageDetection is omitted while it is disabled and has the default rules and no voices.
+	LanguageDetection *LanguageDetection `json:"language_detection,omitempty"`
+	// Normalization is omitted while it has the default settings.
+	Normalization *Normalization `json:"normalization,omitempty"`
 }
 
 func (d *ttsConfigData) validate() error {
@@ -29,12 +80,139 @@ func (d *ttsConfigData) validate() error {
 			"error": err,
 		})
 	}
+	if _, err := validation.IsNumInRange(d.Pitch, 0, 100); err != nil {
+		return apperrors.New(apperrors.Err, "invalid value in field \"$field\": $error", map[string]any{
+			"field": "tts.pitch",
+			"error": err,
+		})
+	}
+	if _, err := validation.IsNumInRange(d.Volume, 0, 100); err != nil {
+		return apperrors.New(apperrors.Err, "invalid value in field \"$field\": $error", map[string]any{
+			"field": "tts.volume",
+			"error": err,
+		})
+	}
+	if err := validateCommandEngines(d.CommandEngines); err != nil {
+		return apperrors.New(apperrors.Err, "invalid value in field \"$field\": $error", map[string]any{
+			"field": "tts.command_engines",
+			"error": err,
+		})
+	}
+	if d.FestivalAddress != "" {
+		if _, _, err := net.SplitHostPort(d.FestivalAddress); err != nil {
+			return apperrors.New(apperrors.Err, "invalid value in field \"$field\": $error", map[string]any{
+				"field": "tts.festival_address",
+				"error": err,
+			})
+		}
+	}
+	if err := validateEngineOrder(d.EngineOrder); err != nil {
+		return apperrors.New(apperrors.Err, "invalid value in field \"$field\": $error", map[string]any{
+			"field": "tts.engine_order",
+			"error": err,
+		})
+	}
+	if d.SelfVoicing != "" && VerbosityLevel(d.SelfVoicing) < 0 {
+		return apperrors.New(apperrors.Err, "invalid value in field \"$field\": unknown verbosity level \"$level\"", map[string]any{
+			"field": "tts.self_voicing",
+			"level": d.SelfVoicing,
+		})
+	}
+	if d.LanguageDetection != nil {
+		if err := d.LanguageDetection.validate(); err != nil {
+			return apperrors.New(apperrors.Err, "invalid value in field \"$field\": $error", map[string]any{
+				"field": "tts.language_detection",
+				"error": err,
+			})
+		}
+	}
+	if d.Normalization != nil {
+		if err := d.Normalization.validate(); err != nil {
+			return apperrors.New(apperrors.Err, "invalid value in field \"$field\": $error", map[string]any{
+				"field": "tts.normalization",
+				"error": err,
+			})
+		}
+	}
+	if d.MaxQueueLength != nil {
+		if _, err := validation.IsNumInRange(*d.MaxQueueLength, 1, 1000); err != nil {
+			return apperrors.New(apperrors.Err, "invalid value in field \"$field\": $error", map[string]any{
+				"field": "tts.max_queue_length",
+				"error": err,
+			})
+		}
+	}
+	return nil
+}
+
+// VerbosityLevel returns the index of the verbosity level in VerbosityLevels, or -1 if it is unknown.
+func VerbosityLevel(name string) int {
+	for i, level := range VerbosityLevels {
+		if level == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func validateEngineOrder(order []string) error {
+	names := make(map[string]bool, len(order))
+	for _, name := range order {
+		if name == "" {
+			return apperrors.New(apperrors.Err, "speech engine name is empty", nil)
+		}
+		if names[name] {
+			return apperrors.New(apperrors.Err, "speech engine \"$name\" is listed more than once", map[string]any{"name": name})
+		}
+		names[name] = true
+	}
 	return nil
 }
 
+// TtsConfig holds the speech settings. Zero values of SpeechRate, Voice, Pitch and Volume
+// mean that the defaults of the synthesizer are used.
 type TtsConfig struct {
 	SynthesizerName string
 	SpeechRate      int
+	Voice           string
+	Pitch           int
+	Volume          int
+	MaxQueueLength  int
+	// CommandEngines are the speech engines defined by the user.
+	CommandEngines []CommandEngine
+	// FestivalAddress is the host and port of the Festival server.
+	FestivalAddress string
+	TextOutput      TextOutput
+	Braille         BrailleOutput
+	// Outputs are the names of the enabled outputs receiving the phrases in addition to the synthesizer.
+	Outputs []string
+	// EngineOrder lists the names of the speech engines in the order they are preferred in.
+	// The engines missing from it follow in the order of their default priority.
+	EngineOrder []string
+	// SelfVoicing is the verbosity level of the speech of the user interface.
+	SelfVoicing string
+	// Lexicon is loaded from its own file rather than from the configuration file.
+	Lexicon *Lexicon
+	// LanguageDetection switches the voice by the language of the text.
+	LanguageDetection *LanguageDetection
+	// Normalization rewrites the text before it is spoken.
+	Normalization *Normalization
+}
+
+func NewTtsConfig() *TtsConfig {
+	return &TtsConfig{
+		MaxQueueLength:  DefaultMaxQueueLength,
+		FestivalAddress: DefaultFestivalAddress,
+		Braille: BrailleOutput{
+			Host:    DefaultBrailleHost,
+			KeyFile: DefaultBrailleKeyFile,
+		},
+		Outputs:           make([]string, 0),
+		SelfVoicing:       DefaultVerbosity,
+		Lexicon:           NewLexicon(),
+		LanguageDetection: NewLanguageDetection(),
+		Normalization:     NewNormalization(),
+	}
 }
 
 func (c *TtsConfig) load(data *ttsConfigData) error {
@@ -43,6 +221,55 @@ func (c *TtsConfig) load(data *ttsConfigData) error {
 	}
 	c.SpeechRate = *data.Rate
 	c.SynthesizerName = *data.SpeechEngine
+	c.Voice = data.Voice
+	c.Pitch = data.Pitch
+	c.Volume = data.Volume
+	c.CommandEngines = data.CommandEngines
+	if data.FestivalAddress != "" {
+		c.FestivalAddress = data.FestivalAddress
+	}
+	if data.TextOutput != nil {
+		c.TextOutput = *data.TextOutput
+	}
+	if data.Braille != nil {
+		if data.Braille.Host != "" {
+			c.Braille.Host = data.Braille.Host
+		}
+		if data.Braille.KeyFile != "" {
+			c.Braille.KeyFile = data.Braille.KeyFile
+		}
+	}
+	if data.Outputs != nil {
+		c.Outputs = data.Outputs
+	}
+	c.EngineOrder = data.EngineOrder
+	if data.SelfVoicing != "" {
+		c.SelfVoicing = data.SelfVoicing
+	}
+	if data.LanguageDetection != nil {
+		c.LanguageDetection = data.LanguageDetection
+		if len(c.LanguageDetection.Rules) == 0 {
+			c.LanguageDetection.Rules = DefaultLanguageRules
+		}
+		if c.LanguageDetection.Voices == nil {
+			c.LanguageDetection.Voices = make(map[string]map[string]string)
+		}
+	}
+	if data.Normalization != nil {
+		c.Normalization = data.Normalization
+		if c.Normalization.Stages == nil {
+			c.Normalization.Stages = make(map[string]bool)
+		}
+		if c.Normalization.Punctuation == "" {
+			c.Normalization.Punctuation = DefaultPunctuation
+		}
+		if c.Normalization.Abbreviations == nil {
+			c.Normalization.Abbreviations = NewNormalization().Abbreviations
+		}
+	}
+	if data.MaxQueueLength != nil {
+		c.MaxQueueLength = *data.MaxQueueLength
+	}
 	return nil
 }
 
@@ -50,8 +277,50 @@ func (c *TtsConfig) save() *ttsConfigData {
 	engine := c.SynthesizerName
 	rate := c.SpeechRate
 	data := &ttsConfigData{
-		SpeechEngine: &engine,
-		Rate:         &rate,
+		SpeechEngine:   &engine,
+		Rate:           &rate,
+		Voice:          c.Voice,
+		Pitch:          c.Pitch,
+		Volume:         c.Volume,
+		CommandEngines: c.CommandEngines,
+		Outputs:        c.Outputs,
+		EngineOrder:    c.EngineOrder,
+	}
+	if c.FestivalAddress != DefaultFestivalAddress {
+		data.FestivalAddress = c.FestivalAddress
+	}
+	if c.TextOutput.ConsolePrefix != "" || c.TextOutput.File != "" || len(c.TextOutput.Command) > 0 {
+		textOutput := c.TextOutput
+		data.TextOutput = &textOutput
+	}
+	if c.Braille.Host != DefaultBrailleHost || c.Braille.KeyFile != DefaultBrailleKeyFile {
+		braille := c.Braille
+		data.Braille = &braille
+	}
+	if c.SelfVoicing != DefaultVerbosity {
+		data.SelfVoicing = c.SelfVoicing
+	}
+	if c.MaxQueueLength != DefaultMaxQueueLength {
+		maxQueueLength := c.MaxQueueLength
+		data.MaxQueueLength = &maxQueueLength
+	}
+	if detection := c.LanguageDetection; detection.Enabled || len(detection.Voices) > 0 || !isDefaultLanguageRules(detection.Rules) {
+		data.LanguageDetection = detection
+	}
+	if !c.Normalization.isDefault() {
+		data.Normalization = c.Normalization
 	}
 	return data
 }
+
+func isDefaultLanguageRules(rules []LanguageRule) bool {
+	if len(rules) != len(DefaultLanguageRules) {
+		return false
+	}
+	for i := range rules {
+		if rules[i] != DefaultLanguageRules[i] {
+			return false
+		}
+	}
+	return true
+}
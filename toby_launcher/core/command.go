@@ -31,5 +31,9 @@ func DefaultGlobalCommands() []Command {
 		&HelpCommand{},
 		&QuitCommand{},
 		&VersionCommand{},
+		&SilenceCommand{},
+		&RescanCommand{},
+		&RepeatCommand{},
+		&VoicingCommand{},
 	}
 }
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"toby_launcher/apperrors"
+	"toby_launcher/config"
 	"toby_launcher/core/version"
 )
 
@@ -56,16 +58,16 @@ func (c *HelpCommand) Execute(ctx *AppContext, ui *UiContext, args []string) (St
 	}
 	desc := state.Description()
 	if desc == "" {
-		ui.DisplayText("Help for this state not found.\r\n")
+		ui.display(ImportantText, "Help for this state not found.\r\n")
 	} else {
-		ui.DisplayText(desc + "\r\n")
+		ui.display(ImportantText, desc+"\r\n")
 	}
-	ui.DisplayText("The following commands are available to you:\r\n")
+	ui.display(ImportantText, "The following commands are available to you:\r\n")
 	for _, cmd := range ui.CommandRegistry.GetLocalCommands() {
-		ui.DisplayText(fmt.Sprintf("%s: (%s).\r\n%s\r\n", cmd.Name(), strings.Join(cmd.Aliases(), ", "), cmd.Description()))
+		ui.display(ImportantText, fmt.Sprintf("%s: (%s).\r\n%s\r\n", cmd.Name(), strings.Join(cmd.Aliases(), ", "), cmd.Description()))
 	}
 	for _, cmd := range ui.CommandRegistry.GetGlobalCommands() {
-		ui.DisplayText(fmt.Sprintf("%s: (%s).\r\n%s\r\n", cmd.Name(), strings.Join(cmd.Aliases(), ", "), cmd.Description()))
+		ui.display(ImportantText, fmt.Sprintf("%s: (%s).\r\n%s\r\n", cmd.Name(), strings.Join(cmd.Aliases(), ", "), cmd.Description()))
 	}
 	return state, nil
 }
@@ -111,6 +113,119 @@ func (c *VersionCommand) Execute(ctx *AppContext, ui *UiContext, args []string)
 	return ctx.GetCurrentState()
 }
 
+type SilenceCommand struct{ BaseCommand }
+
+func (c *SilenceCommand) Name() string {
+	return "silence"
+}
+
+func (c *SilenceCommand) Description() string {
+	return "Stops the current speech and discards all messages waiting to be spoken."
+}
+
+func (c *SilenceCommand) Aliases() []string {
+	return []string{"hush", "flush", "interrupt"}
+}
+
+func (c *SilenceCommand) Execute(ctx *AppContext, ui *UiContext, args []string) (State, error) {
+	ui.TtsManager.Flush()
+	ui.MuteScreen()
+	return ctx.GetCurrentState()
+}
+
+type RepeatCommand struct{ BaseCommand }
+
+func (c *RepeatCommand) Name() string {
+	return "repeat"
+}
+
+func (c *RepeatCommand) Description() string {
+	return "Displays the current screen again and speaks it if self-voicing is enabled."
+}
+
+func (c *RepeatCommand) Aliases() []string {
+	return []string{"again"}
+}
+
+func (c *RepeatCommand) Execute(ctx *AppContext, ui *UiContext, args []string) (State, error) {
+	ui.RepeatScreen()
+	return ctx.GetCurrentState()
+}
+
+type RescanCommand struct{ BaseCommand }
+
+func (c *RescanCommand) Name() string {
+	return "rescan"
+}
+
+func (c *RescanCommand) Description() string {
+	return "Looks for the speech engines installed or started since the launch and reports which of them are available."
+}
+
+func (c *RescanCommand) Execute(ctx *AppContext, ui *UiContext, args []string) (State, error) {
+	RescanSpeechEngines(ui)
+	return ctx.GetCurrentState()
+}
+
+// RescanSpeechEngines probes the speech engines again and displays the reason every unavailable engine is missing.
+func RescanSpeechEngines(ui *UiContext) {
+	statuses := ui.TtsManager.Rescan()
+	available := 0
+	for _, status := range statuses {
+		if status.IsAvailable() {
+			available++
+			ui.DisplayText(fmt.Sprintf("%s: available.\r\n", status.Name))
+		} else {
+			ui.DisplayText(fmt.Sprintf("%s: unavailable, %v.\r\n", status.Name, status.Err))
+		}
+	}
+	msg := fmt.Sprintf("%d of %d speech engines are available.", available, len(statuses))
+	ui.DisplayText(msg + "\r\n")
+	ui.TtsManager.Speak(msg)
+}
+
+type VoicingCommand struct{ BaseCommand }
+
+func (c *VoicingCommand) Name() string {
+	return "voicing"
+}
+
+func (c *VoicingCommand) Description() string {
+	return "Sets the verbosity of the speech of the launcher screens: voicing off, minimal, normal or verbose."
+}
+
+func (c *VoicingCommand) Execute(ctx *AppContext, ui *UiContext, args []string) (State, error) {
+	if len(args) < 2 {
+		ui.DisplayText(fmt.Sprintf("Self-voicing: %s. Available levels: %s.\r\n", ctx.Config.Tts.SelfVoicing, strings.Join(config.VerbosityLevels, ", ")))
+		return ctx.GetCurrentState()
+	}
+	if err := SetSelfVoicing(ctx, ui, strings.ToLower(args[1])); err != nil {
+		ui.DisplayError(err)
+	}
+	return ctx.GetCurrentState()
+}
+
+// SetSelfVoicing sets the verbosity level of the self-voicing UI and announces it.
+func SetSelfVoicing(ctx *AppContext, ui *UiContext, level string) error {
+	if config.VerbosityLevel(level) < 0 {
+		return apperrors.New(apperrors.Err, "Unknown verbosity level \"$level\", expected one of: $levels.", map[string]any{
+			"level":  level,
+			"levels": strings.Join(config.VerbosityLevels, ", "),
+		})
+	}
+	ctx.Config.Tts.SelfVoicing = level
+	msg := fmt.Sprintf("Self-voicing: %s.", level)
+	if level == config.VerbosityLevels[0] {
+		ui.DisplayText(msg + "\r\n")
+		ui.TtsManager.Speak(msg)
+		return nil
+	}
+	// The message is spoken with the screen, which would interrupt it if it was spoken separately.
+	ui.display(ImportantText, msg+"\r\n")
+	ui.RepeatScreen()
+	return nil
+}
+
 type ConfirmCommand struct{ BaseCommand }
 
 func (c *ConfirmCommand) Name() string {
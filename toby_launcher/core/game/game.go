@@ -3,14 +3,17 @@ package game
 import (
 	"os/exec"
 	"toby_launcher/apperrors"
+	"toby_launcher/config"
+	"toby_launcher/core/lobby"
 )
 
 type RawGameData struct {
-	Description string   `json:"description"`
-	Iwads       []string `json:"iwads"`
-	Config      string   `json:"config"`
-	Files       []string `json:"files"`
-	Params      []string `json:"params"`
+	Description string                `json:"description"`
+	Iwads       []string              `json:"iwads"`
+	Config      string                `json:"config"`
+	Files       []string              `json:"files"`
+	Params      []string              `json:"params"`
+	Match       *config.MatchSettings `json:"match"`
 }
 
 func (d RawGameData) validate() error {
@@ -20,6 +23,11 @@ func (d RawGameData) validate() error {
 	if len(d.Iwads) == 0 {
 		return apperrors.New(apperrors.Err, "field \"iwads\" is empty", nil)
 	}
+	if d.Match != nil {
+		if err := ValidateMatch(d.Match); err != nil {
+			return apperrors.New(apperrors.Err, "invalid field \"match\": $error", map[string]any{"error": err})
+		}
+	}
 	return nil
 }
 
@@ -32,6 +40,8 @@ type GameData struct {
 	Iwads       []string
 	Files       []string
 	Params      []string
+	// Match holds the default match setup of a deathmatch game.
+	Match *config.MatchSettings
 }
 
 type Game struct {
@@ -39,3 +49,22 @@ type Game struct {
 	cmd       *exec.Cmd
 	IsRunning bool
 }
+
+// LaunchOptions holds the settings of a single game launch that are not part of the game description.
+type LaunchOptions struct {
+	// Players is the number of players of a hosted network game, 0 for a single player game.
+	Players int
+	// Lobby is the network game to join.
+	Lobby *lobby.Announcement
+	Match *config.MatchSettings
+	// Preset is the name of the launch preset to apply, empty for none.
+	Preset string
+}
+
+func (o *LaunchOptions) isHost() bool {
+	return o != nil && o.Players > 1
+}
+
+func (o *LaunchOptions) isJoin() bool {
+	return o != nil && o.Lobby != nil
+}
@@ -6,8 +6,10 @@ import (
 	"os/exec"
 	"sort"
 	"strings"
+	"sync"
 	"toby_launcher/apperrors"
 	"toby_launcher/config"
+	"toby_launcher/core/lobby"
 	"toby_launcher/core/logger"
 	"toby_launcher/core/tts"
 	"toby_launcher/utils/file_utils"
@@ -21,6 +23,8 @@ type GameManager struct {
 	iwads         []string
 	currentGame   *Game
 	textProcessor *TextProcessor
+	announcerMu   sync.Mutex
+	announcer     *lobby.Announcer
 	Params        *GameParams
 }
 
@@ -36,11 +40,19 @@ func NewGameManager(cfg *config.Config, logger logger.Logger, tts *tts.TtsManage
 		iwads:         make([]string, 0, 10),
 		textProcessor: NewTextProcessor(cfg, logger, tts),
 	}
-	gp, err := newGameParams(cfg.Gzdoom.GameParams)
+	gp, err := newGameParams(cfg.Paths.GameParamsPath(), cfg.Gzdoom.GameParams)
 	if err != nil {
 		logger.Error(err)
 	}
 	manager.Params = gp
+	for name, params := range cfg.Gzdoom.PerGameParams {
+		if err := gp.newLayer(params).ApplyConfig(params); err != nil {
+			logger.Error(apperrors.New(apperrors.Err, "Warning: incorrect GZDoom params of game $game in configuration:\n$errors", map[string]any{
+				"game":   name,
+				"errors": err,
+			}))
+		}
+	}
 	if err := manager.loadGames(); err != nil {
 		return nil, err
 	}
@@ -51,7 +63,6 @@ func (m *GameManager) Release() {
 	if err := m.StopGame(); err != nil {
 		m.logger.Error(err)
 	}
-	m.Params.save()
 }
 
 func (m *GameManager) loadGames() error {
@@ -76,6 +87,7 @@ func (m *GameManager) loadGames() error {
 			Iwads:       g.Iwads,
 			Files:       g.Files,
 			Params:      g.Params,
+			Match:       g.Match,
 		}
 		m.games = append(m.games, game)
 	}
@@ -123,7 +135,50 @@ func (m *GameManager) Iwads() []string {
 	return m.iwads
 }
 
-func (m *GameManager) StartGame(gameData *GameData) error {
+func (m *GameManager) FindGame(name string) *GameData {
+	for _, game := range m.games {
+		if game.Name == name {
+			return game
+		}
+	}
+	return nil
+}
+
+// PresetsForGame returns the sorted names of the launch presets that apply to the game.
+func (m *GameManager) PresetsForGame(data *GameData) []string {
+	names := make([]string, 0, len(m.config.Gzdoom.LaunchPresets))
+	for name, preset := range m.config.Gzdoom.LaunchPresets {
+		if preset.AppliesTo(data.Name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GameParamsFor returns the params of the game layered on top of the global params.
+func (m *GameManager) GameParamsFor(data *GameData) *GameParams {
+	params, exists := m.config.Gzdoom.PerGameParams[data.Name]
+	if !exists {
+		params = make(config.GzdoomParams)
+		m.config.Gzdoom.PerGameParams[data.Name] = params
+	}
+	return m.Params.newLayer(params)
+}
+
+func (m *GameManager) launchPreset(opts *LaunchOptions) *config.LaunchPreset {
+	if opts == nil || opts.Preset == "" {
+		return nil
+	}
+	preset, exists := m.config.Gzdoom.LaunchPresets[opts.Preset]
+	if !exists {
+		m.logger.Printf("Warning: launch preset %s is not found.\r\n", opts.Preset)
+		return nil
+	}
+	return preset
+}
+
+func (m *GameManager) StartGame(gameData *GameData, opts *LaunchOptions) error {
 	if m.currentGame != nil && m.currentGame.IsRunning {
 		return apperrors.New(apperrors.Err, "Another game is already running", nil)
 	}
@@ -131,7 +186,18 @@ func (m *GameManager) StartGame(gameData *GameData) error {
 	if err != nil {
 		return apperrors.New(apperrors.Err, "Failed to find gzdoom: $error", map[string]any{"error": err})
 	}
-	args := m.buildGameArgs(gameData)
+	if opts.isJoin() {
+		if err := m.checkLobbyFiles(gameData, opts.Lobby); err != nil {
+			return err
+		}
+	}
+	var announcement *lobby.Announcement
+	if opts.isHost() {
+		if announcement, err = m.newAnnouncement(gameData, opts); err != nil {
+			return err
+		}
+	}
+	args := m.buildGameArgs(gameData, opts)
 	cmd := exec.Command(gzdoomPath, args...)
 	cmd.Stdout = m.textProcessor // TextProcessor will handle output
 	cmd.Stderr = m.textProcessor
@@ -150,6 +216,9 @@ func (m *GameManager) StartGame(gameData *GameData) error {
 		m.currentGame = nil
 		return apperrors.New(apperrors.Err, "Failed to start game: $error", map[string]any{"error": err})
 	}
+	if announcement != nil {
+		m.startAnnouncer(*announcement)
+	}
 	go m.handleGameProcess()
 	return nil
 }
@@ -161,6 +230,7 @@ func (m *GameManager) StopGame() error {
 	if err := m.currentGame.cmd.Process.Kill(); err != nil {
 		return apperrors.New(apperrors.Err, "Failed to stop game: $error", map[string]any{"error": err})
 	}
+	m.stopAnnouncer()
 	m.currentGame.IsRunning = false
 	m.currentGame = nil
 	return nil
@@ -182,22 +252,41 @@ func (m *GameManager) handleGameProcess() {
 		if err != nil && m.currentGame.IsRunning {
 			m.logger.Error(apperrors.New(apperrors.Err, "Game process error: $error", map[string]any{"error": err}))
 		}
+		m.stopAnnouncer()
 		m.tts.Speak("Game finished.")
 		m.logger.Printf("Game finished.\r\n")
 		m.currentGame.IsRunning = false
 		m.currentGame = nil
-		m.textProcessor.startProcessing = false
+		m.textProcessor.reset()
 	}
 }
 
 // buildGameArgs constructs the command-line arguments for gzdoom.
-func (m *GameManager) buildGameArgs(data *GameData) []string {
+// The arguments are merged in the following order, later ones take precedence in GZDoom:
+// GZDoom params layered as defaults, global configuration, configuration of the game and params of the launch preset,
+// additional launch params from the configuration, params of the game, arguments of the launch preset,
+// match settings, game files and network settings.
+func (m *GameManager) buildGameArgs(data *GameData, opts *LaunchOptions) []string {
+	preset := m.launchPreset(opts)
 	args := make([]string, 0, 5+len(data.Files)*2+len(data.Params)*2+len(m.config.Gzdoom.AdditionalLaunchParams)*2)
 	args = append(args, "-stdout")
 	if m.config.Gzdoom.Logging {
 		args = append(args, "+logfile", m.config.Paths.GzdoomLogFilePath())
 	}
-	gameParams := m.Params.toCmdArgs()
+	params := m.GameParamsFor(data)
+	if preset != nil && len(preset.Params) > 0 {
+		presetParams, err := params.withOverrides(preset.Params)
+		if err != nil {
+			m.logger.Error(apperrors.New(apperrors.Err, "Warning: incorrect GZDoom params in launch preset $preset:\n$errors", map[string]any{
+				"preset": opts.Preset,
+				"errors": err,
+			}))
+		}
+		if presetParams != nil {
+			params = presetParams
+		}
+	}
+	gameParams, script := params.toCmdArgs()
 	if len(gameParams) > 0 {
 		args = append(args, gameParams...)
 	}
@@ -211,6 +300,16 @@ func (m *GameManager) buildGameArgs(data *GameData) []string {
 			args = append(args, strings.Split(param, " ")...)
 		}
 	}
+	if preset != nil {
+		for _, param := range preset.Args {
+			args = append(args, strings.Split(param, " ")...)
+		}
+	}
+	if opts != nil && opts.Match != nil {
+		matchParams, matchScript := matchArgs(opts.Match)
+		args = append(args, matchParams...)
+		script = append(script, matchScript...)
+	}
 	if data.Config != "" {
 		configPath := m.config.Paths.GameFilePath(data.Config)
 		if file_utils.Exists(configPath) {
@@ -219,20 +318,54 @@ func (m *GameManager) buildGameArgs(data *GameData) []string {
 			m.logger.Printf("Warning: configuration file %s for game %s is not found.\r\n", configPath, data.Name)
 		}
 	}
-	for _, iwad := range data.Iwads {
+	iwads := data.Iwads
+	if opts.isJoin() {
+		iwads = []string{opts.Lobby.Iwad}
+	}
+	if iwad := m.resolveIwad(data, iwads); iwad != "" {
+		args = append(args, "-iwad", iwad)
+	}
+	files := m.resolveFiles(data)
+	if len(files) > 0 {
+		args = append(args, "-file")
+		args = append(args, files...)
+	}
+	switch {
+	case opts.isHost():
+		args = append(args, "-host", fmt.Sprintf("%d", opts.Players), "-port", fmt.Sprintf("%d", lobby.DefaultGamePort))
+	case opts.isJoin():
+		args = append(args, "-join", opts.Lobby.Address())
+	}
+	if len(script) > 0 {
+		scriptPath := m.config.Paths.LaunchScriptPath()
+		if err := file_utils.WriteFile(scriptPath, []byte(strings.Join(script, "\n")+"\n")); err != nil {
+			m.logger.Error(err)
+		} else {
+			args = append(args, "+exec", scriptPath)
+		}
+	}
+	return args
+}
+
+// resolveIwad returns the first of the given iwads that is present in the files directory.
+func (m *GameManager) resolveIwad(data *GameData, iwads []string) string {
+	for _, iwad := range iwads {
 		iwadPath := m.config.Paths.GameFilePath(iwad)
 		iwadLower := strings.ToLower(iwad)
 		iwadLowerPath := m.config.Paths.GameFilePath(iwadLower)
 		if file_utils.Exists(iwadPath) {
-			args = append(args, "-iwad", iwad)
-			break
+			return iwad
 		} else if file_utils.Exists(iwadLowerPath) {
-			args = append(args, "-iwad", iwadLower)
-			break
+			return iwadLower
 		} else {
 			m.logger.Printf("Warning: iwad file %s for game %s is not found.\r\n", iwadPath, data.Name)
 		}
 	}
+	return ""
+}
+
+// resolveFiles returns the additional files of the game that are present in the files directory.
+func (m *GameManager) resolveFiles(data *GameData) []string {
 	files := make([]string, 0, len(data.Files))
 	for _, file := range data.Files {
 		filePath := m.config.Paths.GameFilePath(file)
@@ -242,9 +375,5 @@ func (m *GameManager) buildGameArgs(data *GameData) []string {
 			m.logger.Printf("Warning: additional file %s for game %s is not found.\r\n", filePath, data.Name)
 		}
 	}
-	if len(files) > 0 {
-		args = append(args, "-file")
-		args = append(args, files...)
-	}
-	return args
+	return files
 }
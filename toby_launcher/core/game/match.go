@@ -0,0 +1,193 @@
+package game
+
+import (
+	"fmt"
+	"toby_launcher/apperrors"
+	"toby_launcher/config"
+)
+
+type MatchMode struct {
+	Name        string
+	Description string
+	arg         string
+}
+
+var MatchModes = []MatchMode{
+	{Name: "deathmatch", Description: "Deathmatch, items do not respawn", arg: "-deathmatch"},
+	{Name: "altdeath", Description: "Alternative deathmatch, items respawn", arg: "-altdeath"},
+}
+
+// DmFlag is a named bit of the GZDoom dmflags console variable.
+type DmFlag struct {
+	Name        string
+	Description string
+	bit         int
+}
+
+var DmFlags = []DmFlag{
+	{Name: "no_health", Description: "no health items", bit: 1 << 0},
+	{Name: "no_items", Description: "no powerups", bit: 1 << 1},
+	{Name: "weapons_stay", Description: "weapons stay after pickup", bit: 1 << 2},
+	{Name: "same_level", Description: "stay on the same map when the limit is hit", bit: 1 << 6},
+	{Name: "spawn_farthest", Description: "spawn farthest from other players", bit: 1 << 7},
+	{Name: "force_respawn", Description: "force respawn", bit: 1 << 8},
+	{Name: "no_armor", Description: "no armor", bit: 1 << 9},
+	{Name: "no_exit", Description: "forbid exiting the map", bit: 1 << 10},
+	{Name: "infinite_ammo", Description: "infinite ammo", bit: 1 << 11},
+	{Name: "no_monsters", Description: "no monsters", bit: 1 << 12},
+	{Name: "monsters_respawn", Description: "monsters respawn", bit: 1 << 13},
+	{Name: "items_respawn", Description: "items respawn", bit: 1 << 14},
+	{Name: "fast_monsters", Description: "fast monsters", bit: 1 << 15},
+	{Name: "no_jump", Description: "forbid jumping", bit: 1 << 16},
+	{Name: "no_freelook", Description: "forbid free look", bit: 1 << 18},
+	{Name: "no_crouch", Description: "forbid crouching", bit: 1 << 22},
+}
+
+// BotOption is a GZDoom bot_* console variable turned on or off in the match setup.
+type BotOption struct {
+	Name        string
+	Description string
+	cvar        string
+}
+
+var BotOptions = []BotOption{
+	{Name: "observer", Description: "watch the bots as a spectator", cvar: "bot_observer"},
+	{Name: "watersplash", Description: "bots make splashes in water", cvar: "bot_watersplash"},
+}
+
+const (
+	MaxMatchLimit = 1000
+	MaxBots       = 7
+	// botDelayTics delays adding bots until the map is loaded, bots can't be added earlier.
+	botDelayTics = 35
+)
+
+func findMatchMode(name string) (MatchMode, bool) {
+	for _, mode := range MatchModes {
+		if mode.Name == name {
+			return mode, true
+		}
+	}
+	return MatchMode{}, false
+}
+
+func findDmFlag(name string) (DmFlag, bool) {
+	for _, flag := range DmFlags {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+	return DmFlag{}, false
+}
+
+func findBotOption(name string) (BotOption, bool) {
+	for _, option := range BotOptions {
+		if option.Name == name {
+			return option, true
+		}
+	}
+	return BotOption{}, false
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func setName(names []string, name string, enabled bool) []string {
+	result := make([]string, 0, len(names)+1)
+	for _, n := range names {
+		if n != name {
+			result = append(result, n)
+		}
+	}
+	if enabled {
+		result = append(result, name)
+	}
+	return result
+}
+
+func MatchFlagEnabled(s *config.MatchSettings, name string) bool {
+	return containsName(s.Flags, name)
+}
+
+func SetMatchFlag(s *config.MatchSettings, name string, enabled bool) {
+	s.Flags = setName(s.Flags, name, enabled)
+}
+
+func BotOptionEnabled(s *config.MatchSettings, name string) bool {
+	return containsName(s.BotOptions, name)
+}
+
+func SetBotOption(s *config.MatchSettings, name string, enabled bool) {
+	s.BotOptions = setName(s.BotOptions, name, enabled)
+}
+
+func ValidateMatch(s *config.MatchSettings) error {
+	if _, ok := findMatchMode(s.Mode); !ok {
+		return apperrors.New(apperrors.Err, "unknown match mode \"$mode\"", map[string]any{"mode": s.Mode})
+	}
+	if s.Fraglimit < 0 || s.Fraglimit > MaxMatchLimit || s.Timelimit < 0 || s.Timelimit > MaxMatchLimit {
+		return apperrors.New(apperrors.Err, "match limits must be between 0 and $max", map[string]any{"max": MaxMatchLimit})
+	}
+	if s.Bots < 0 || s.Bots > MaxBots {
+		return apperrors.New(apperrors.Err, "the number of bots must be between 0 and $max", map[string]any{"max": MaxBots})
+	}
+	for _, f := range s.Flags {
+		if _, ok := findDmFlag(f); !ok {
+			return apperrors.New(apperrors.Err, "unknown match flag \"$flag\"", map[string]any{"flag": f})
+		}
+	}
+	for _, o := range s.BotOptions {
+		if _, ok := findBotOption(o); !ok {
+			return apperrors.New(apperrors.Err, "unknown bot option \"$option\"", map[string]any{"option": o})
+		}
+	}
+	return nil
+}
+
+// matchArgs returns the command-line arguments and the startup script commands of a match.
+func matchArgs(s *config.MatchSettings) ([]string, []string) {
+	args := make([]string, 0, 12)
+	if mode, ok := findMatchMode(s.Mode); ok {
+		args = append(args, mode.arg)
+	}
+	if s.Map != "" {
+		args = append(args, "+map", s.Map)
+	}
+	args = append(args, "+fraglimit", fmt.Sprintf("%d", s.Fraglimit))
+	args = append(args, "+timelimit", fmt.Sprintf("%d", s.Timelimit))
+	teamplay := 0
+	if s.Teamplay {
+		teamplay = 1
+	}
+	args = append(args, "+teamplay", fmt.Sprintf("%d", teamplay))
+	dmflags := 0
+	for _, name := range s.Flags {
+		if flag, ok := findDmFlag(name); ok {
+			dmflags |= flag.bit
+		}
+	}
+	args = append(args, "+dmflags", fmt.Sprintf("%d", dmflags))
+	if s.Bots > 0 {
+		for _, option := range BotOptions {
+			enabled := 0
+			if BotOptionEnabled(s, option.Name) {
+				enabled = 1
+			}
+			args = append(args, "+"+option.cvar, fmt.Sprintf("%d", enabled))
+		}
+	}
+	script := make([]string, 0, 1+s.Bots)
+	if s.Bots > 0 {
+		script = append(script, fmt.Sprintf("wait %d", botDelayTics))
+		for i := 0; i < s.Bots; i++ {
+			script = append(script, "addbot")
+		}
+	}
+	return args, script
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+	"toby_launcher/apperrors"
+	"toby_launcher/core/lobby"
+)
+
+// FilesHash returns a digest of the iwad and the additional files of the game.
+// Players of a network game must have identical files, otherwise GZDoom desynchronizes.
+func (m *GameManager) FilesHash(data *GameData, iwad string) (string, error) {
+	hash := sha256.New()
+	files := append([]string{iwad}, m.resolveFiles(data)...)
+	for _, file := range files {
+		fileHash, err := hashFile(m.config.Paths.GameFilePath(file))
+		if err != nil {
+			return "", apperrors.New(apperrors.Err, "Failed to read file $file: $error", map[string]any{
+				"file":  file,
+				"error": err,
+			})
+		}
+		hash.Write([]byte(strings.ToLower(file)))
+		hash.Write(fileHash)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func hashFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+func (m *GameManager) newAnnouncement(data *GameData, opts *LaunchOptions) (*lobby.Announcement, error) {
+	if opts.Players > lobby.MaxPlayers {
+		return nil, apperrors.New(apperrors.Err, "A network game can have at most $max players.", map[string]any{"max": lobby.MaxPlayers})
+	}
+	iwad := m.resolveIwad(data, data.Iwads)
+	if iwad == "" {
+		return nil, apperrors.New(apperrors.Err, "No iwad file was found for game $game.", map[string]any{"game": data.Name})
+	}
+	hash, err := m.FilesHash(data, iwad)
+	if err != nil {
+		return nil, err
+	}
+	return &lobby.Announcement{
+		Game:       data.Name,
+		Iwad:       iwad,
+		FilesHash:  hash,
+		Port:       lobby.DefaultGamePort,
+		MaxPlayers: opts.Players,
+	}, nil
+}
+
+// checkLobbyFiles makes sure that the local files of the game match the files of the host.
+func (m *GameManager) checkLobbyFiles(data *GameData, a *lobby.Announcement) error {
+	iwad := m.resolveIwad(data, []string{a.Iwad})
+	if iwad == "" {
+		return apperrors.New(apperrors.Err, "You don't have the iwad file $iwad used by the host.", map[string]any{"iwad": a.Iwad})
+	}
+	hash, err := m.FilesHash(data, iwad)
+	if err != nil {
+		return err
+	}
+	if hash != a.FilesHash {
+		return apperrors.New(apperrors.Err, "Your files for game $game differ from the files of the host. Make sure you have the same versions of the iwad and all game files.", map[string]any{"game": data.Name})
+	}
+	return nil
+}
+
+func (m *GameManager) startAnnouncer(a lobby.Announcement) {
+	announcer, err := lobby.NewAnnouncer(a, m.config.Gzdoom.LobbyAddress)
+	if err != nil {
+		m.logger.Error(apperrors.New(apperrors.Err, "Failed to announce the game on the local network: $error", map[string]any{"error": err}))
+		return
+	}
+	m.announcerMu.Lock()
+	defer m.announcerMu.Unlock()
+	m.announcer = announcer
+}
+
+// stopAnnouncer is called both when the game is stopped and when its process exits,
+// so the announcer is taken under the mutex and stopped once.
+func (m *GameManager) stopAnnouncer() {
+	m.announcerMu.Lock()
+	announcer := m.announcer
+	m.announcer = nil
+	m.announcerMu.Unlock()
+	if announcer != nil {
+		announcer.Stop()
+	}
+}
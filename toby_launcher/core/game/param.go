@@ -2,127 +2,327 @@ package game
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"toby_launcher/apperrors"
 )
 
-type GameParam interface {
-	toCmdArgs() []string
-	value() any
-	set(value any) error
-}
-
-type VidBackend int
+type ParamKind string
 
 const (
-	OpenGLBackend   VidBackend = 0
-	VulkanBackend   VidBackend = 1
-	OpenGLESBackend VidBackend = 2
+	BoolParamKind   ParamKind = "bool"
+	IntParamKind    ParamKind = "int"
+	FloatParamKind  ParamKind = "float"
+	EnumParamKind   ParamKind = "enum"
+	StringParamKind ParamKind = "string"
+	CvarParamKind   ParamKind = "cvar"
 )
 
-var VideoBackends []VidBackend = []VidBackend{
-	OpenGLBackend, VulkanBackend, OpenGLESBackend,
+// SoundfontChoices is the source of choices listing the discovered MIDI soundfonts.
+const SoundfontChoices = "soundfonts"
+
+type ParamChoice struct {
+	Value any    `json:"value"`
+	Label string `json:"label"`
+}
+
+// ParamSpec is the declarative description of a GZDoom param loaded from the params catalog.
+// A param is passed to GZDoom either as command-line arguments built from the Args template,
+// where $value is replaced by the value, or as a console variable named Cvar set at startup.
+// Bool params may use different templates for the enabled and the disabled state.
+// Params of the same Group are shown in a separate settings menu.
+// ChoicesFrom names a source of choices discovered at runtime, offered in addition to free input.
+type ParamSpec struct {
+	Key         string        `json:"key"`
+	Kind        ParamKind     `json:"kind"`
+	Description string        `json:"description"`
+	Group       string        `json:"group"`
+	ChoicesFrom string        `json:"choices_from"`
+	Args        string        `json:"args"`
+	ArgsTrue    string        `json:"args_true"`
+	ArgsFalse   string        `json:"args_false"`
+	Cvar        string        `json:"cvar"`
+	Default     any           `json:"default"`
+	Min         *float64      `json:"min"`
+	Max         *float64      `json:"max"`
+	Choices     []ParamChoice `json:"choices"`
+}
+
+type GameParam interface {
+	Spec() *ParamSpec
+	// Parse converts user input into a value of the param.
+	Parse(input string) (any, error)
+	// Format returns a human readable representation of a value of the param.
+	Format(value any) string
+	normalize(value any) (any, error)
+	argValue(value any) string
+	argsTemplate(value any) string
 }
 
-func (b VidBackend) String() string {
-	switch b {
-	case OpenGLBackend:
-		return "OpenGL"
-	case VulkanBackend:
-		return "Vulkan"
-	case OpenGLESBackend:
-		return "OpenGL ES"
+func newGameParam(spec ParamSpec) (GameParam, error) {
+	if spec.Key == "" {
+		return nil, apperrors.New(apperrors.Err, "param key is missing", nil)
+	}
+	if spec.ChoicesFrom != "" && spec.ChoicesFrom != SoundfontChoices {
+		return nil, apperrors.New(apperrors.Err, "param $key has unknown source of choices \"$source\"", map[string]any{"key": spec.Key, "source": spec.ChoicesFrom})
+	}
+	base := baseParam{spec: spec}
+	var param GameParam
+	switch spec.Kind {
+	case BoolParamKind:
+		param = &boolParam{base}
+	case IntParamKind:
+		param = &intParam{base}
+	case FloatParamKind:
+		param = &floatParam{base}
+	case EnumParamKind:
+		if len(spec.Choices) == 0 {
+			return nil, apperrors.New(apperrors.Err, "enum param $key has no choices", map[string]any{"key": spec.Key})
+		}
+		param = &enumParam{base}
+	case StringParamKind:
+		param = &stringParam{base}
+	case CvarParamKind:
+		if spec.Cvar == "" {
+			base.spec.Cvar = spec.Key
+		}
+		param = &stringParam{base}
 	default:
-		return "unknown"
+		return nil, apperrors.New(apperrors.Err, "param $key has unknown kind \"$kind\"", map[string]any{"key": spec.Key, "kind": spec.Kind})
+	}
+	if spec.Default != nil {
+		value, err := param.normalize(spec.Default)
+		if err != nil {
+			return nil, apperrors.New(apperrors.Err, "invalid default value of param $key: $error", map[string]any{"key": spec.Key, "error": err})
+		}
+		param.Spec().Default = value
 	}
+	return param, nil
 }
 
-func (b VidBackend) isValid() bool {
-	switch b {
-	case OpenGLBackend, VulkanBackend, OpenGLESBackend:
-		return true
-	default:
-		return false
+// paramCmdArgs returns the command-line arguments passing the value of the param.
+func paramCmdArgs(p GameParam, value any) []string {
+	template := p.argsTemplate(value)
+	if template == "" {
+		return []string{}
 	}
+	argValue := p.argValue(value)
+	fields := strings.Fields(template)
+	args := make([]string, 0, len(fields))
+	for _, field := range fields {
+		args = append(args, strings.ReplaceAll(field, "$value", argValue))
+	}
+	return args
+}
+
+// paramCvarCommand returns the console command setting the console variable of the param.
+func paramCvarCommand(p GameParam, value any) string {
+	argValue := strings.ReplaceAll(p.argValue(value), "\"", "\\\"")
+	return fmt.Sprintf("%s \"%s\"", p.Spec().Cvar, argValue)
 }
 
-type videoBackendParam struct {
-	backend VidBackend
+type baseParam struct {
+	spec ParamSpec
 }
 
-func newVideoBackendParam() GameParam {
-	return &videoBackendParam{backend: OpenGLBackend}
+func (p *baseParam) Spec() *ParamSpec {
+	return &p.spec
 }
 
-func (p *videoBackendParam) toCmdArgs() []string {
-	return []string{"+vid_preferbackend", fmt.Sprintf("%d", p.backend)}
+func (p *baseParam) argValue(value any) string {
+	return fmt.Sprintf("%v", value)
 }
 
-func (p *videoBackendParam) value() any {
-	return p.backend
+func (p *baseParam) argsTemplate(value any) string {
+	return p.spec.Args
 }
 
-func (p *videoBackendParam) set(value any) error {
-	var backend VidBackend
+func (p *baseParam) Format(value any) string {
+	if value == nil {
+		return "not set"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func (p *baseParam) checkRange(num float64) error {
+	if p.spec.Min != nil && num < *p.spec.Min {
+		return apperrors.New(apperrors.Err, "The provided number must not be less than $min.", map[string]any{"min": *p.spec.Min})
+	}
+	if p.spec.Max != nil && num > *p.spec.Max {
+		return apperrors.New(apperrors.Err, "The provided number must not exceed $max.", map[string]any{"max": *p.spec.Max})
+	}
+	return nil
+}
+
+func (p *baseParam) invalidType(value any, expected string) error {
+	return apperrors.New(apperrors.Err, "invalid type of value $value for param $key, expected $expected", map[string]any{
+		"value":    value,
+		"key":      p.spec.Key,
+		"expected": expected,
+	})
+}
+
+func toFloat(value any) (float64, bool) {
 	switch v := value.(type) {
-	case VidBackend:
-		backend = v
 	case float64:
-		backend = VidBackend(int(v))
+		return v, true
+	case float32:
+		return float64(v), true
 	case int:
-		backend = VidBackend(v)
+		return float64(v), true
+	case int64:
+		return float64(v), true
 	default:
-		return apperrors.New(apperrors.Err, "invalid type for vid_preferbackend: expected VidBackend or number, got $type", map[string]any{"type": fmt.Sprintf("%T", value)})
+		return 0, false
 	}
-	if !backend.isValid() {
-		return apperrors.New(apperrors.Err, "invalid VidBackend value: $value", map[string]any{"value": backend})
+}
+
+type boolParam struct{ baseParam }
+
+func (p *boolParam) normalize(value any) (any, error) {
+	status, ok := value.(bool)
+	if !ok {
+		return nil, p.invalidType(value, "bool")
 	}
-	p.backend = backend
-	return nil
+	return status, nil
+}
+
+func (p *boolParam) Parse(input string) (any, error) {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "1", "true", "yes", "on", "enable":
+		return true, nil
+	case "0", "false", "no", "off", "disable":
+		return false, nil
+	}
+	return nil, apperrors.New(apperrors.Err, "You must enter yes or no.", nil)
 }
 
-type toggleSwitcher struct {
-	active bool
+func (p *boolParam) Format(value any) string {
+	switch value {
+	case true:
+		return "enabled"
+	case false:
+		return "disabled"
+	}
+	return p.baseParam.Format(value)
 }
 
-func (s *toggleSwitcher) value() any {
-	return s.active
+func (p *boolParam) argValue(value any) string {
+	if value == true {
+		return "1"
+	}
+	return "0"
 }
 
-func (s *toggleSwitcher) set(value any) error {
-	status, ok := value.(bool)
+func (p *boolParam) argsTemplate(value any) string {
+	if value == true && p.spec.ArgsTrue != "" {
+		return p.spec.ArgsTrue
+	}
+	if value == false && p.spec.ArgsFalse != "" {
+		return p.spec.ArgsFalse
+	}
+	return p.spec.Args
+}
+
+type intParam struct{ baseParam }
+
+func (p *intParam) normalize(value any) (any, error) {
+	num, ok := toFloat(value)
+	if !ok || num != math.Trunc(num) {
+		return nil, p.invalidType(value, "integer")
+	}
+	if err := p.checkRange(num); err != nil {
+		return nil, err
+	}
+	return int(num), nil
+}
+
+func (p *intParam) Parse(input string) (any, error) {
+	num, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil {
+		return nil, apperrors.New(apperrors.Err, "You must enter an integer.", nil)
+	}
+	return p.normalize(num)
+}
+
+type floatParam struct{ baseParam }
+
+func (p *floatParam) normalize(value any) (any, error) {
+	num, ok := toFloat(value)
 	if !ok {
-		return apperrors.New(apperrors.Err, "invalid type of value $value, expected bool", map[string]any{"value": value})
+		return nil, p.invalidType(value, "number")
 	}
-	s.active = status
-	return nil
+	if err := p.checkRange(num); err != nil {
+		return nil, err
+	}
+	return num, nil
 }
 
-type MusicParam struct{ toggleSwitcher }
+func (p *floatParam) Parse(input string) (any, error) {
+	num, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(input), ",", "."), 64)
+	if err != nil {
+		return nil, apperrors.New(apperrors.Err, "You must enter a number.", nil)
+	}
+	return p.normalize(num)
+}
 
-func newMusicParam() GameParam {
-	param := &MusicParam{}
-	param.active = true
-	return param
+func (p *floatParam) argValue(value any) string {
+	num, _ := toFloat(value)
+	return strconv.FormatFloat(num, 'f', -1, 64)
 }
 
-func (p *MusicParam) toCmdArgs() []string {
-	if p.active {
-		return []string{}
+type enumParam struct{ baseParam }
+
+func (p *enumParam) findChoice(value any) (ParamChoice, bool) {
+	for _, choice := range p.spec.Choices {
+		if choice.Value == value {
+			return choice, true
+		}
+		num, isNum := toFloat(value)
+		choiceNum, choiceIsNum := toFloat(choice.Value)
+		if isNum && choiceIsNum && num == choiceNum {
+			return choice, true
+		}
 	}
-	return []string{"-nomusic"}
+	return ParamChoice{}, false
 }
 
-type SoundfxParam struct{ toggleSwitcher }
+func (p *enumParam) normalize(value any) (any, error) {
+	choice, ok := p.findChoice(value)
+	if !ok {
+		return nil, apperrors.New(apperrors.Err, "invalid value $value for param $key", map[string]any{"value": value, "key": p.spec.Key})
+	}
+	return choice.Value, nil
+}
 
-func newSoundfxParam() GameParam {
-	param := &SoundfxParam{}
-	param.active = true
-	return param
+func (p *enumParam) Parse(input string) (any, error) {
+	input = strings.TrimSpace(input)
+	for _, choice := range p.spec.Choices {
+		if strings.EqualFold(input, choice.Label) || input == fmt.Sprintf("%v", choice.Value) {
+			return choice.Value, nil
+		}
+	}
+	return nil, apperrors.New(apperrors.Err, "There is no such choice.", nil)
 }
 
-func (p *SoundfxParam) toCmdArgs() []string {
-	if p.active {
-		return []string{}
+func (p *enumParam) Format(value any) string {
+	if choice, ok := p.findChoice(value); ok && choice.Label != "" {
+		return choice.Label
+	}
+	return p.baseParam.Format(value)
+}
+
+type stringParam struct{ baseParam }
+
+func (p *stringParam) normalize(value any) (any, error) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, p.invalidType(value, "string")
 	}
-	return []string{"-nosfx"}
+	return str, nil
+}
+
+func (p *stringParam) Parse(input string) (any, error) {
+	return p.normalize(strings.TrimSpace(input))
 }
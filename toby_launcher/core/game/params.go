@@ -3,51 +3,85 @@ package game
 import (
 	"toby_launcher/apperrors"
 	"toby_launcher/config"
+	"toby_launcher/utils/file_utils"
 )
 
-var (
-	videoBackendParamKey string = "vid_preferbackend"
-	musicParamKey        string = "music"
-	soundfxParamKey      string = "sound_fx"
-)
-
+// GameParams holds the values of the GZDoom params described by the params catalog.
+// Values are stored in the configuration. Params are layered: a param without a value
+// inherits the value of the parent layer, and the top layer falls back to the default of the param.
 type GameParams struct {
-	params map[string]GameParam
-	config config.GzdoomParams
+	catalog []GameParam
+	params  map[string]GameParam
+	config  config.GzdoomParams
+	parent  *GameParams
 }
 
-func newGameParams(cfg config.GzdoomParams) (*GameParams, error) {
-	params := map[string]GameParam{
-		videoBackendParamKey: newVideoBackendParam(),
-		musicParamKey:        newMusicParam(),
-		soundfxParamKey:      newSoundfxParam(),
+func loadParamCatalog(path string) ([]GameParam, error) {
+	var specs []ParamSpec
+	if err := file_utils.LoadData(path, &specs); err != nil {
+		return nil, apperrors.New(apperrors.Err, "Failed to load GZDoom params catalog: $error", map[string]any{"error": err})
+	}
+	catalog := make([]GameParam, 0, len(specs))
+	seen := make(map[string]bool, len(specs))
+	errs := apperrors.NewErrors(nil)
+	for _, spec := range specs {
+		param, err := newGameParam(spec)
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+		if seen[spec.Key] {
+			errs.Add(apperrors.New(apperrors.Err, "param $key is defined more than once", map[string]any{"key": spec.Key}))
+			continue
+		}
+		seen[spec.Key] = true
+		catalog = append(catalog, param)
 	}
+	if errs.Count() > 0 {
+		return catalog, apperrors.New(apperrors.Err, "Warning: in file $file, skipping incorrect GZDoom params:\n$errors", map[string]any{
+			"file":   path,
+			"errors": errs,
+		})
+	}
+	return catalog, nil
+}
+
+func newGameParams(catalogPath string, cfg config.GzdoomParams) (*GameParams, error) {
+	catalog, catalogErr := loadParamCatalog(catalogPath)
 	gp := &GameParams{
-		params: params,
-		config: cfg,
+		catalog: catalog,
+		params:  make(map[string]GameParam, len(catalog)),
+		config:  cfg,
+	}
+	for _, param := range catalog {
+		gp.params[param.Spec().Key] = param
+	}
+	// The configuration is checked against the params that did load, even if some of the catalog is broken.
+	errs := apperrors.NewErrors(nil)
+	if catalogErr != nil {
+		errs.Add(catalogErr)
 	}
-	var warn error
 	if err := gp.ApplyConfig(cfg); err != nil {
-		warn = apperrors.New(apperrors.Err, "Warning: incorrect GZDoom params in configuration:\n$errors", map[string]any{"errors": err})
+		errs.Add(apperrors.New(apperrors.Err, "Warning: incorrect GZDoom params in configuration:\n$errors", map[string]any{"errors": err}))
 	}
-	gp.updateConfig()
-	if warn != nil {
-		return gp, warn
+	if errs.Count() > 0 {
+		return gp, errs
 	}
 	return gp, nil
 }
 
+// ApplyConfig sets the params to the given values.
+// Values that can not be applied are reported and removed from the given configuration.
 func (p *GameParams) ApplyConfig(cfg config.GzdoomParams) error {
 	errs := apperrors.NewErrors(nil)
 	for key, val := range cfg {
 		if _, exists := p.params[key]; !exists {
-			err := apperrors.New(apperrors.Err, "param with key $key does not exist", map[string]any{"key": key})
-			errs.Add(err)
+			errs.Add(apperrors.New(apperrors.Err, "param with key $key does not exist", map[string]any{"key": key}))
 			continue
 		}
-		if err := p.params[key].set(val); err != nil {
+		if err := p.Set(key, val); err != nil {
 			errs.Add(err)
-			continue
+			delete(cfg, key)
 		}
 	}
 	if errs.Count() > 0 {
@@ -56,63 +90,140 @@ func (p *GameParams) ApplyConfig(cfg config.GzdoomParams) error {
 	return nil
 }
 
-func (p *GameParams) updateConfig() {
-	for key, param := range p.params {
-		if _, exists := p.config[key]; !exists {
-			p.config[key] = param.value()
+// toCmdArgs returns the command-line arguments and the lines of the launch script passing the params.
+func (p *GameParams) toCmdArgs() ([]string, []string) {
+	args := make([]string, 0, 2*len(p.catalog))
+	script := make([]string, 0, len(p.catalog))
+	for _, param := range p.catalog {
+		value := p.Value(param.Spec().Key)
+		if value == nil {
+			continue
+		}
+		if param.Spec().Cvar != "" {
+			script = append(script, paramCvarCommand(param, value))
+			continue
+		}
+		args = append(args, paramCmdArgs(param, value)...)
+	}
+	return args, script
+}
+
+// withOverrides returns a copy of the params with the given values applied on top.
+func (p *GameParams) withOverrides(overrides config.GzdoomParams) (*GameParams, error) {
+	values := make(config.GzdoomParams, len(p.config)+len(overrides))
+	for key, value := range p.config {
+		values[key] = value
+	}
+	gp := &GameParams{
+		catalog: p.catalog,
+		params:  p.params,
+		config:  values,
+		parent:  p.parent,
+	}
+	errs := apperrors.NewErrors(nil)
+	for key, value := range overrides {
+		if err := gp.Set(key, value); err != nil {
+			errs.Add(err)
 		}
 	}
+	if errs.Count() > 0 {
+		return gp, errs
+	}
+	return gp, nil
+}
+
+// CheckOverrides reports whether the given values can be applied to the params.
+func (p *GameParams) CheckOverrides(overrides config.GzdoomParams) error {
+	_, err := p.withOverrides(overrides)
+	return err
 }
 
-func (p *GameParams) save() {
-	for key, param := range p.params {
-		p.config[key] = param.value()
+// newLayer returns params stored in the given configuration that inherit unset values from p.
+func (p *GameParams) newLayer(cfg config.GzdoomParams) *GameParams {
+	return &GameParams{
+		catalog: p.catalog,
+		params:  p.params,
+		config:  cfg,
+		parent:  p,
 	}
 }
 
-func (p *GameParams) toCmdArgs() []string {
-	args := make([]string, 0, 2*len(p.params))
-	for _, param := range p.params {
-		args = append(args, param.toCmdArgs()...)
+// IsLayer reports whether the params inherit values from another layer.
+func (p *GameParams) IsLayer() bool {
+	return p.parent != nil
+}
+
+// Catalog returns the params in the order of the catalog.
+func (p *GameParams) Catalog() []GameParam {
+	return p.catalog
+}
+
+func (p *GameParams) Param(key string) (GameParam, error) {
+	param, exists := p.params[key]
+	if !exists {
+		return nil, apperrors.New(apperrors.Err, "param with key $key does not exist", map[string]any{"key": key})
 	}
-	return args
+	return param, nil
 }
 
-func (p *GameParams) Params() map[string]GameParam {
-	return p.params
+// Value returns the value of the param, or the inherited value if the value is not set.
+// Nil means that the param is not passed to GZDoom.
+func (p *GameParams) Value(key string) any {
+	if value, exists := p.config[key]; exists {
+		return value
+	}
+	return p.Inherited(key)
 }
 
-func (p *GameParams) set(key string, value any) error {
-	if _, exists := p.params[key]; !exists {
-		return apperrors.New(apperrors.Err, "param with key $key does not exist", map[string]any{"key": key})
+// Inherited returns the value the param has when it is not set in this layer.
+func (p *GameParams) Inherited(key string) any {
+	if p.parent != nil {
+		return p.parent.Value(key)
 	}
-	if err := p.params[key].set(value); err != nil {
-		return err
+	if param, exists := p.params[key]; exists {
+		return param.Spec().Default
 	}
-	p.config[key] = value
 	return nil
 }
 
-func (p *GameParams) VideoBackend() VidBackend {
-	return p.params[videoBackendParamKey].value().(VidBackend)
+// IsSet reports whether the value of the param is set in this layer.
+func (p *GameParams) IsSet(key string) bool {
+	_, exists := p.config[key]
+	return exists
 }
 
-func (p *GameParams) SetVideoBackend(backend VidBackend) error {
-	return p.set(videoBackendParamKey, backend)
+func (p *GameParams) Set(key string, value any) error {
+	param, err := p.Param(key)
+	if err != nil {
+		return err
+	}
+	normalized, err := param.normalize(value)
+	if err != nil {
+		return err
+	}
+	p.config[key] = normalized
+	return nil
 }
 
-func (p *GameParams) MusicPtr() *bool {
-	return &p.params[musicParamKey].(*MusicParam).active
+// Reset removes the value of the param from this layer, so the inherited value is used.
+func (p *GameParams) Reset(key string) {
+	delete(p.config, key)
 }
 
-func (p *GameParams) SetMusic(status bool) error {
-	return p.set(musicParamKey, status)
+// Format returns a human readable representation of the value of the param.
+func (p *GameParams) Format(key string) string {
+	return p.formatValue(key, p.Value(key))
 }
 
-func (p *GameParams) SoundfxPtr() *bool {
-	return &p.params[soundfxParamKey].(*SoundfxParam).active
+// FormatInherited returns a human readable representation of the inherited value of the param.
+func (p *GameParams) FormatInherited(key string) string {
+	return p.formatValue(key, p.Inherited(key))
 }
 
-func (p *GameParams) SetSoundfx(status bool) error {
-	return p.set(soundfxParamKey, status)
+func (p *GameParams) formatValue(key string, value any) string {
+	param, err := p.Param(key)
+	if err != nil {
+		return "unknown"
+	}
+	return param.Format(value)
 }
@@ -11,15 +11,33 @@ import (
 	"toby_launcher/utils/file_utils"
 )
 
+// SubstitutionData replaces the matches of the pattern. A Markup replacement is written in the speech
+// markup, e.g. "<emphasis>$1</emphasis>". If any substitution produces markup, all lines are escaped
+// before the substitutions, so the patterns see "&", "<" and ">" as "&amp;", "&lt;" and "&gt;".
 type SubstitutionData struct {
 	Pattern     string `json:"pattern"`
 	Replacement string `json:"replacement"`
+	Markup      bool   `json:"markup"`
+}
+
+// CategoryData describes a category of game messages recognized by patterns.
+// Messages of a category are spoken with its priority.
+type CategoryData struct {
+	Name      string         `json:"name"`
+	Priority  string         `json:"priority"`
+	Patterns  []string       `json:"patterns"`
+	RateLimit *RateLimitData `json:"rate_limit"`
+	// AllowRepeats exempts the category from the suppression of duplicates, e.g. for menu items.
+	AllowRepeats bool `json:"allow_repeats"`
 }
 
 type TextRulesData struct {
-	Separator     string             `json:"separator"`
-	Exclusions    []string           `json:"exclusions"`
-	Substitutions []SubstitutionData `json:"substitutions"`
+	Separator       string             `json:"separator"`
+	Exclusions      []string           `json:"exclusions"`
+	Substitutions   []SubstitutionData `json:"substitutions"`
+	DefaultPriority string             `json:"default_priority"`
+	Categories      []CategoryData     `json:"categories"`
+	Suppression     SuppressionData    `json:"suppression"`
 }
 
 type Substitution struct {
@@ -27,6 +45,13 @@ type Substitution struct {
 	replacement string
 }
 
+type Category struct {
+	name         string
+	priority     tts.Priority
+	patterns     []*regexp.Regexp
+	allowRepeats bool
+}
+
 // TextProcessor processes game output based on rules from tts_lines.json.
 type TextProcessor struct {
 	logger          logger.Logger
@@ -35,17 +60,25 @@ type TextProcessor struct {
 	separator       *regexp.Regexp
 	exclusions      []*regexp.Regexp
 	substitutions   []Substitution
+	categories      []Category
+	defaultPriority tts.Priority
+	suppressor      *Suppressor
 	startProcessing bool
+	// isMarkup is set if the substitutions produce the speech markup.
+	isMarkup bool
 }
 
 // NewTextProcessor creates a new TextProcessor instance.
-func NewTextProcessor(cfg *config.Config, logger logger.Logger, tts *tts.TtsManager) *TextProcessor {
+func NewTextProcessor(cfg *config.Config, logger logger.Logger, ttsManager *tts.TtsManager) *TextProcessor {
 	processor := &TextProcessor{
 		logger:          logger,
-		tts:             tts,
+		tts:             ttsManager,
 		config:          cfg,
 		exclusions:      make([]*regexp.Regexp, 0, 20),
 		substitutions:   make([]Substitution, 0, 20),
+		categories:      make([]Category, 0, 10),
+		defaultPriority: tts.NormalPriority,
+		suppressor:      NewSuppressor(logger, ttsManager),
 		startProcessing: false,
 	}
 	if err := processor.loadRules(); err != nil {
@@ -81,6 +114,9 @@ func (p *TextProcessor) loadRules() error {
 		}
 		p.exclusions = append(p.exclusions, re)
 	}
+	for _, rule := range rules.Substitutions {
+		p.isMarkup = p.isMarkup || rule.Markup
+	}
 	for _, rule := range rules.Substitutions {
 		re, err := regexp.Compile(rule.Pattern)
 		if err != nil {
@@ -91,15 +127,88 @@ func (p *TextProcessor) loadRules() error {
 			}))
 			continue
 		}
+		replacement := rule.Replacement
+		if p.isMarkup && !rule.Markup {
+			replacement = tts.EscapeMarkup(replacement)
+		}
 		subst := Substitution{
 			pattern:     re,
-			replacement: rule.Replacement,
+			replacement: replacement,
 		}
 		p.substitutions = append(p.substitutions, subst)
 	}
+	if rules.DefaultPriority != "" {
+		priority, err := tts.ParsePriority(rules.DefaultPriority)
+		if err != nil {
+			p.logger.Error(apperrors.New(apperrors.Err, "Invalid default priority in file $file: $error", map[string]any{"file": path, "error": err}))
+		} else {
+			p.defaultPriority = priority
+		}
+	}
+	for _, data := range rules.Categories {
+		category, err := p.newCategory(data)
+		if err != nil {
+			p.logger.Error(apperrors.New(apperrors.Err, "Invalid category \"$category\" in file $file: $error", map[string]any{
+				"category": data.Name,
+				"file":     path,
+				"error":    err,
+			}))
+			continue
+		}
+		p.categories = append(p.categories, category)
+		if data.RateLimit != nil {
+			p.suppressor.setRateLimit(category.name, *data.RateLimit)
+		}
+	}
+	p.suppressor.configure(rules.Suppression, p.isMarkup)
 	return nil
 }
 
+func (p *TextProcessor) newCategory(data CategoryData) (Category, error) {
+	category := Category{
+		name:         data.Name,
+		priority:     p.defaultPriority,
+		patterns:     make([]*regexp.Regexp, 0, len(data.Patterns)),
+		allowRepeats: data.AllowRepeats,
+	}
+	if data.Priority != "" {
+		priority, err := tts.ParsePriority(data.Priority)
+		if err != nil {
+			return category, err
+		}
+		category.priority = priority
+	}
+	if data.RateLimit != nil && (data.RateLimit.Count <= 0 || data.RateLimit.Period <= 0) {
+		return category, apperrors.New(apperrors.Err, "rate limit count and period must be positive", nil)
+	}
+	for _, pattern := range data.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return category, err
+		}
+		category.patterns = append(category.patterns, re)
+	}
+	return category, nil
+}
+
+// category returns the first category the message matches, or a category with the default priority.
+func (p *TextProcessor) category(line string) *Category {
+	for i := range p.categories {
+		for _, re := range p.categories[i].patterns {
+			if re.MatchString(line) {
+				return &p.categories[i]
+			}
+		}
+	}
+	return &Category{priority: p.defaultPriority}
+}
+
+// reset prepares the processor for the output of the next game.
+func (p *TextProcessor) reset() {
+	p.startProcessing = false
+	p.suppressor.reset()
+}
+
 func (p *TextProcessor) Write(data []byte) (int, error) {
 	scanner := bufio.NewScanner(bytes.NewReader(data))
 	for scanner.Scan() {
@@ -120,12 +229,20 @@ func (p *TextProcessor) Write(data []byte) (int, error) {
 			}
 		}
 		processedLine := line
+		if p.isMarkup {
+			processedLine = tts.EscapeMarkup(line)
+		}
 		for _, rule := range p.substitutions {
 			processedLine = rule.pattern.ReplaceAllString(processedLine, rule.replacement)
 		}
-		if processedLine != "" {
-			p.tts.Speak(processedLine)
-			p.logger.DebugPrintf("speaking: %s\r\n", processedLine)
+		if processedLine == "" {
+			continue
+		}
+		// Categories are recognized by the text as it is spoken.
+		if p.isMarkup {
+			p.suppressor.Speak(processedLine, p.category(tts.StripMarkup(processedLine)))
+		} else {
+			p.suppressor.Speak(processedLine, p.category(processedLine))
 		}
 	}
 	if err := scanner.Err(); err != nil {
@@ -0,0 +1,46 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var soundfontExtensions = []string{".sf2", ".sf3"}
+
+func isSoundfont(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range soundfontExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Soundfonts returns the paths of MIDI soundfonts found in the soundfont directories.
+func (m *GameManager) Soundfonts() []string {
+	soundfonts := make([]string, 0, 10)
+	seen := make(map[string]bool)
+	for _, dir := range m.config.Paths.SoundfontDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		found := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			if entry.IsDir() || !isSoundfont(entry.Name()) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !seen[path] {
+				seen[path] = true
+				found = append(found, path)
+			}
+		}
+		sort.Strings(found)
+		soundfonts = append(soundfonts, found...)
+	}
+	return soundfonts
+}
@@ -0,0 +1,180 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"toby_launcher/core/logger"
+	"toby_launcher/core/tts"
+)
+
+// SuppressionData configures the suppression of repeated and flooding game messages.
+// DuplicateWindow is the time in milliseconds, counted from the first occurrence of a message,
+// during which the identical message is not spoken again. If CollapseRepeats is set, the suppressed
+// repeats are announced as "message, N times" when the window closes.
+type SuppressionData struct {
+	DuplicateWindow int  `json:"duplicate_window"`
+	CollapseRepeats bool `json:"collapse_repeats"`
+}
+
+// RateLimitData limits a category to Count messages per Period milliseconds.
+type RateLimitData struct {
+	Count  int `json:"count"`
+	Period int `json:"period"`
+}
+
+type rateLimit struct {
+	count  int
+	period time.Duration
+	sent   []time.Time
+}
+
+// allow reports whether a message can be spoken now and records it if so.
+func (l *rateLimit) allow(now time.Time) bool {
+	kept := l.sent[:0]
+	for _, t := range l.sent {
+		if now.Sub(t) < l.period {
+			kept = append(kept, t)
+		}
+	}
+	l.sent = kept
+	if len(l.sent) >= l.count {
+		return false
+	}
+	l.sent = append(l.sent, now)
+	return true
+}
+
+// clock is the source of the time of the suppressor, which the tests replace.
+type clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) stopper
+}
+
+type stopper interface {
+	Stop() bool
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) stopper {
+	return time.AfterFunc(d, f)
+}
+
+// speaker speaks the messages which are not suppressed, it is implemented by tts.TtsManager.
+type speaker interface {
+	SpeakWithPriority(text string, priority tts.Priority) int
+	SpeakMarkup(text string, priority tts.Priority) int
+}
+
+type repeat struct {
+	count    int
+	priority tts.Priority
+	timer    stopper
+}
+
+// Suppressor drops duplicate messages and messages exceeding the rate limit of their category
+// before they reach the speech queue.
+type Suppressor struct {
+	mu       sync.Mutex
+	logger   logger.Logger
+	tts      speaker
+	clock    clock
+	window   time.Duration
+	collapse bool
+	repeats  map[string]*repeat
+	limits   map[string]*rateLimit
+	// isMarkup is set if the messages are written in the speech markup.
+	isMarkup bool
+}
+
+func NewSuppressor(logger logger.Logger, ttsManager *tts.TtsManager) *Suppressor {
+	return newSuppressor(logger, ttsManager, systemClock{})
+}
+
+func newSuppressor(logger logger.Logger, speaker speaker, clock clock) *Suppressor {
+	return &Suppressor{
+		logger:  logger,
+		tts:     speaker,
+		clock:   clock,
+		repeats: make(map[string]*repeat),
+		limits:  make(map[string]*rateLimit),
+	}
+}
+
+func (s *Suppressor) configure(data SuppressionData, isMarkup bool) {
+	s.window = time.Duration(data.DuplicateWindow) * time.Millisecond
+	s.collapse = data.CollapseRepeats
+	s.isMarkup = isMarkup
+}
+
+func (s *Suppressor) setRateLimit(category string, data RateLimitData) {
+	s.limits[category] = &rateLimit{
+		count:  data.Count,
+		period: time.Duration(data.Period) * time.Millisecond,
+		sent:   make([]time.Time, 0, data.Count),
+	}
+}
+
+// Speak speaks the message unless it is suppressed. The repeats do not extend the window,
+// so a message repeated more often than the window is spoken again once in every window.
+func (s *Suppressor) Speak(text string, category *Category) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	priority := category.priority
+	if s.window > 0 && !category.allowRepeats {
+		if r, exists := s.repeats[text]; exists {
+			r.count++
+			s.logger.DebugPrintf("suppressed repeat: %s\r\n", text)
+			return
+		}
+		r := &repeat{count: 1, priority: priority}
+		r.timer = s.clock.AfterFunc(s.window, func() { s.closeWindow(text, r) })
+		s.repeats[text] = r
+	}
+	if limit, exists := s.limits[category.name]; exists && !limit.allow(s.clock.Now()) {
+		s.logger.DebugPrintf("rate limited: %s\r\n", text)
+		return
+	}
+	s.speak(text, priority)
+	s.logger.DebugPrintf("speaking: %s\r\n", text)
+}
+
+func (s *Suppressor) speak(text string, priority tts.Priority) {
+	if s.isMarkup {
+		s.tts.SpeakMarkup(text, priority)
+	} else {
+		s.tts.SpeakWithPriority(text, priority)
+	}
+}
+
+// closeWindow forgets the message and announces how many times it was repeated.
+func (s *Suppressor) closeWindow(text string, r *repeat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// The timer may fire after a reset, when the message may have opened another window.
+	if s.repeats[text] != r {
+		return
+	}
+	delete(s.repeats, text)
+	if s.collapse && r.count > 1 {
+		s.speak(fmt.Sprintf("%s, %d times", text, r.count), r.priority)
+	}
+}
+
+// reset forgets the messages seen so far.
+func (s *Suppressor) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for text, r := range s.repeats {
+		r.timer.Stop()
+		delete(s.repeats, text)
+	}
+	for _, limit := range s.limits {
+		limit.sent = limit.sent[:0]
+	}
+}
@@ -0,0 +1,204 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+	"toby_launcher/core/tts"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Printf(format string, v ...any)      {}
+func (nopLogger) Error(err error)                     {}
+func (nopLogger) InfoPrintf(format string, v ...any)  {}
+func (nopLogger) DebugPrintf(format string, v ...any) {}
+func (nopLogger) DebugError(err error)                {}
+func (nopLogger) Release()                            {}
+
+// fakeClock is advanced by the test, which fires the timers that are due.
+type fakeClock struct {
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+func (t *fakeTimer) Stop() bool {
+	active := !t.stopped
+	t.stopped = true
+	return active
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) stopper {
+	timer := &fakeTimer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+	return timer
+}
+
+// advance moves the clock forward, firing the timers in order of their time.
+func (c *fakeClock) advance(d time.Duration) {
+	end := c.now.Add(d)
+	for {
+		sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].at.Before(c.timers[j].at) })
+		if len(c.timers) == 0 || c.timers[0].at.After(end) {
+			break
+		}
+		timer := c.timers[0]
+		c.timers = c.timers[1:]
+		if timer.stopped {
+			continue
+		}
+		timer.stopped = true
+		c.now = timer.at
+		timer.f()
+	}
+	c.now = end
+}
+
+// fakeSpeaker records the spoken messages, marking those spoken as markup.
+type fakeSpeaker struct {
+	spoken []string
+}
+
+func (s *fakeSpeaker) SpeakWithPriority(text string, priority tts.Priority) int {
+	s.spoken = append(s.spoken, text)
+	return len(s.spoken)
+}
+
+func (s *fakeSpeaker) SpeakMarkup(text string, priority tts.Priority) int {
+	s.spoken = append(s.spoken, "markup: "+text)
+	return len(s.spoken)
+}
+
+// suppressorStep speaks the text after the delay, or only advances the clock if the text is empty.
+type suppressorStep struct {
+	after time.Duration
+	text  string
+}
+
+func TestSuppressor(t *testing.T) {
+	beacon := &Category{name: "beacon", priority: tts.NormalPriority}
+	menu := &Category{name: "menu", priority: tts.UiPriority, allowRepeats: true}
+	tests := []struct {
+		name        string
+		suppression SuppressionData
+		isMarkup    bool
+		limit       *RateLimitData
+		category    *Category
+		steps       []suppressorStep
+		want        []string
+	}{
+		{
+			name:        "repeats are dropped within the window",
+			suppression: SuppressionData{DuplicateWindow: 1000},
+			category:    beacon,
+			steps:       []suppressorStep{{0, "wall"}, {100, "wall"}, {100, "door"}, {100, "wall"}},
+			want:        []string{"wall", "door"},
+		},
+		{
+			name:        "the window does not slide with frequent repeats",
+			suppression: SuppressionData{DuplicateWindow: 1000},
+			category:    beacon,
+			steps: []suppressorStep{
+				{0, "wall"}, {400, "wall"}, {400, "wall"}, {400, "wall"}, {400, "wall"}, {400, "wall"},
+			},
+			want: []string{"wall", "wall"},
+		},
+		{
+			name:        "collapsed repeats are announced when the window closes",
+			suppression: SuppressionData{DuplicateWindow: 1000, CollapseRepeats: true},
+			category:    beacon,
+			steps:       []suppressorStep{{0, "wall"}, {100, "wall"}, {100, "wall"}, {800, ""}, {100, "wall"}},
+			want:        []string{"wall", "wall, 3 times", "wall"},
+		},
+		{
+			name:        "a single message is not collapsed",
+			suppression: SuppressionData{DuplicateWindow: 1000, CollapseRepeats: true},
+			category:    beacon,
+			steps:       []suppressorStep{{0, "wall"}, {2000, ""}},
+			want:        []string{"wall"},
+		},
+		{
+			name:        "markup is spoken as markup",
+			suppression: SuppressionData{DuplicateWindow: 1000, CollapseRepeats: true},
+			isMarkup:    true,
+			category:    beacon,
+			steps:       []suppressorStep{{0, "wall"}, {100, "wall"}, {1000, ""}},
+			want:        []string{"markup: wall", "markup: wall, 2 times"},
+		},
+		{
+			name:        "the category may allow repeats",
+			suppression: SuppressionData{DuplicateWindow: 1000},
+			category:    menu,
+			steps:       []suppressorStep{{0, "New game"}, {100, "New game"}},
+			want:        []string{"New game", "New game"},
+		},
+		{
+			name:        "no window disables the suppression",
+			suppression: SuppressionData{},
+			category:    beacon,
+			steps:       []suppressorStep{{0, "wall"}, {0, "wall"}},
+			want:        []string{"wall", "wall"},
+		},
+		{
+			name:     "the rate limit drops the flood",
+			limit:    &RateLimitData{Count: 2, Period: 1000},
+			category: beacon,
+			steps: []suppressorStep{
+				{0, "one"}, {100, "two"}, {100, "three"}, {700, "four"}, {100, "five"}, {100, "six"},
+			},
+			want: []string{"one", "two", "five", "six"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			speaker := &fakeSpeaker{}
+			s := newSuppressor(nopLogger{}, speaker, clock)
+			s.configure(test.suppression, test.isMarkup)
+			if test.limit != nil {
+				s.setRateLimit(test.category.name, *test.limit)
+			}
+			for _, step := range test.steps {
+				clock.advance(step.after * time.Millisecond)
+				if step.text != "" {
+					s.Speak(step.text, test.category)
+				}
+			}
+			if fmt.Sprint(speaker.spoken) != fmt.Sprint(test.want) {
+				t.Errorf("spoken %q, want %q", speaker.spoken, test.want)
+			}
+		})
+	}
+}
+
+func TestSuppressorReset(t *testing.T) {
+	beacon := &Category{name: "beacon", priority: tts.NormalPriority}
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	speaker := &fakeSpeaker{}
+	s := newSuppressor(nopLogger{}, speaker, clock)
+	s.configure(SuppressionData{DuplicateWindow: 1000, CollapseRepeats: true}, false)
+	s.setRateLimit("beacon", RateLimitData{Count: 2, Period: 1000})
+	s.Speak("wall", beacon)
+	s.Speak("wall", beacon)
+	s.Speak("door", beacon)
+	s.reset()
+	// The messages and the rate limit are forgotten, and the stopped window is not collapsed.
+	s.Speak("wall", beacon)
+	s.Speak("door", beacon)
+	clock.advance(2 * time.Second)
+	want := []string{"wall", "door", "wall", "door"}
+	if fmt.Sprint(speaker.spoken) != fmt.Sprint(want) {
+		t.Errorf("spoken %q, want %q", speaker.spoken, want)
+	}
+}
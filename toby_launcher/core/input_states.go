@@ -0,0 +1,122 @@
+package core
+
+import (
+	"fmt"
+	"toby_launcher/core/validation"
+)
+
+type IntInputState struct {
+	BaseState
+	name string
+	min  int
+	max  int
+	get  func() int
+	set  func(int) error
+}
+
+func NewIntInputState(name string, min, max int, get func() int, set func(int) error) *IntInputState {
+	return &IntInputState{name: name, min: min, max: max, get: get, set: set}
+}
+
+func (s *IntInputState) Name() string {
+	return "change " + s.name
+}
+
+func (s *IntInputState) Description() string {
+	return fmt.Sprintf("You need to enter an integer from %d to %d.", s.min, s.max)
+}
+
+func (s *IntInputState) Display(ctx *AppContext, ui *UiContext) {
+	ui.DisplayText(fmt.Sprintf("Enter %s (%d-%d).\r\n", s.name, s.min, s.max))
+	if s.get != nil {
+		ui.DisplayText(fmt.Sprintf("Current value: %d.\r\n", s.get()))
+	}
+}
+
+func (s *IntInputState) Handle(ctx *AppContext, ui *UiContext, input string) (State, error) {
+	num, err := validation.ParseIntInRange(input, s.min, s.max)
+	if err != nil {
+		return s, err
+	}
+	if err := s.set(num); err != nil {
+		return s, err
+	}
+	ui.DisplayText(fmt.Sprintf("You have set %s: %d.\r\n", s.name, num))
+	return ctx.GetPreviousState()
+}
+
+func (s *IntInputState) Commands() []Command {
+	return []Command{&BackCommand{}}
+}
+
+func NewIntMenuOption(id int, name string, min, max int, get func() int, set func(int) error) *MenuOption {
+	return &MenuOption{
+		Id:          id,
+		Description: "Change $name ($value).",
+		Params: func() map[string]any {
+			return map[string]any{"name": name, "value": get()}
+		},
+		NextState: func() (State, error) {
+			return NewIntInputState(name, min, max, get, set), nil
+		},
+	}
+}
+
+type TextInputState struct {
+	BaseState
+	name string
+	get  func() string
+	set  func(string) error
+}
+
+func NewTextInputState(name string, get func() string, set func(string) error) *TextInputState {
+	return &TextInputState{name: name, get: get, set: set}
+}
+
+func (s *TextInputState) Name() string {
+	return "change " + s.name
+}
+
+func (s *TextInputState) Description() string {
+	return "You need to enter a text value. To leave the value unchanged, press \"enter\"."
+}
+
+func (s *TextInputState) Display(ctx *AppContext, ui *UiContext) {
+	ui.DisplayText(fmt.Sprintf("Enter %s.\r\n", s.name))
+	if s.get != nil && s.get() != "" {
+		ui.DisplayText(fmt.Sprintf("Current value: %s.\r\n", s.get()))
+	}
+}
+
+func (s *TextInputState) Handle(ctx *AppContext, ui *UiContext, input string) (State, error) {
+	if input == "" {
+		ui.DisplayText("The value remains unchanged.\r\n")
+		return ctx.GetPreviousState()
+	}
+	if err := s.set(input); err != nil {
+		return s, err
+	}
+	ui.DisplayText(fmt.Sprintf("You have set %s: %s.\r\n", s.name, input))
+	return ctx.GetPreviousState()
+}
+
+func (s *TextInputState) Commands() []Command {
+	return []Command{&BackCommand{}}
+}
+
+func NewTextMenuOption(id int, name string, get func() string, set func(string) error) *MenuOption {
+	return &MenuOption{
+		Id:          id,
+		Description: "Change $name ($value).",
+		Params: func() map[string]any {
+			value := get()
+			if value == "" {
+				value = "not set"
+			}
+			return map[string]any{"name": name, "value": value}
+		},
+		NextState: func() (State, error) {
+			return NewTextInputState(name, get, set), nil
+		},
+	}
+}
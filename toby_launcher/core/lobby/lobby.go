@@ -0,0 +1,199 @@
+package lobby
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DiscoveryPort is the UDP port used by launchers to announce hosted games.
+	DiscoveryPort = 5030
+	// DefaultGamePort is the port GZDoom listens on when hosting a game.
+	DefaultGamePort = 5029
+	// MaxPlayers is the largest number of players GZDoom supports in a network game.
+	MaxPlayers = 8
+
+	announceInterval = time.Second
+	protocolMagic    = "toby_launcher_lobby"
+	protocolVersion  = 1
+	maxPacketSize    = 4096
+)
+
+// Announcement describes a game hosted by a launcher on the local network.
+type Announcement struct {
+	Game      string `json:"game"`
+	Iwad      string `json:"iwad"`
+	FilesHash string `json:"files_hash"`
+	Port      int    `json:"port"`
+	// MaxPlayers is the number of players GZDoom of the host waits for before the game starts.
+	// The launcher cannot see who has joined, so the free places are not announced.
+	MaxPlayers int `json:"max_players"`
+	// Host is the address the announcement was received from.
+	Host string `json:"-"`
+}
+
+// Address returns the address that should be passed to GZDoom's -join.
+func (a *Announcement) Address() string {
+	return net.JoinHostPort(a.Host, strconv.Itoa(a.Port))
+}
+
+type packet struct {
+	Magic   string `json:"magic"`
+	Version int    `json:"version"`
+	Announcement
+}
+
+func encodeAnnouncement(a Announcement) ([]byte, error) {
+	return json.Marshal(packet{
+		Magic:        protocolMagic,
+		Version:      protocolVersion,
+		Announcement: a,
+	})
+}
+
+func decodeAnnouncement(data []byte) (*Announcement, error) {
+	var p packet
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if p.Magic != protocolMagic {
+		return nil, fmt.Errorf("unknown packet")
+	}
+	if p.Version != protocolVersion {
+		return nil, fmt.Errorf("unsupported lobby protocol version %d", p.Version)
+	}
+	if p.Game == "" || p.Port <= 0 {
+		return nil, fmt.Errorf("incomplete announcement")
+	}
+	return &p.Announcement, nil
+}
+
+// Announcer periodically broadcasts an announcement until it is stopped.
+type Announcer struct {
+	conn *net.UDPConn
+	data []byte
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// resolveTarget resolves the address announcements are sent to, "host" or "host:port".
+// An empty address means the limited broadcast address, and a missing port means DiscoveryPort.
+func resolveTarget(target string) (*net.UDPAddr, error) {
+	if target == "" {
+		return &net.UDPAddr{IP: net.IPv4bcast, Port: DiscoveryPort}, nil
+	}
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		target = net.JoinHostPort(target, strconv.Itoa(DiscoveryPort))
+	}
+	addr, err := net.ResolveUDPAddr("udp4", target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lobby address %s: %w", target, err)
+	}
+	return addr, nil
+}
+
+// NewAnnouncer starts sending the announcement to the target address, see Discover.
+func NewAnnouncer(a Announcement, target string) (*Announcer, error) {
+	data, err := encodeAnnouncement(a)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := resolveTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp4", nil, addr)
+	if err != nil {
+		return nil, err
+	}
+	announcer := &Announcer{
+		conn: conn,
+		data: data,
+		stop: make(chan struct{}),
+	}
+	announcer.wg.Add(1)
+	go announcer.run()
+	return announcer, nil
+}
+
+func (a *Announcer) run() {
+	defer a.wg.Done()
+	ticker := time.NewTicker(announceInterval)
+	defer ticker.Stop()
+	for {
+		// Send errors are transient (e.g. the network is temporarily down), so keep trying.
+		_, _ = a.conn.Write(a.data)
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop stops broadcasting and releases the socket.
+func (a *Announcer) Stop() {
+	a.once.Do(func() {
+		close(a.stop)
+		a.wg.Wait()
+		a.conn.Close()
+	})
+}
+
+// Discover listens for announcements sent to the target address for the given duration
+// and returns the games found. The target is the same as the one of the announcers,
+// an empty one means the broadcasts to DiscoveryPort.
+func Discover(target string, timeout time.Duration) ([]*Announcement, error) {
+	addr, err := resolveTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	lc := net.ListenConfig{Control: reuseAddrControl}
+	pc, err := lc.ListenPacket(context.Background(), "udp4", fmt.Sprintf(":%d", addr.Port))
+	if err != nil {
+		return nil, err
+	}
+	defer pc.Close()
+	if err := pc.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	found := make(map[string]*Announcement)
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				break
+			}
+			return nil, err
+		}
+		a, err := decodeAnnouncement(buf[:n])
+		if err != nil {
+			continue
+		}
+		udpAddr, ok := addr.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+		a.Host = udpAddr.IP.String()
+		found[a.Address()] = a
+	}
+	lobbies := make([]*Announcement, 0, len(found))
+	for _, a := range found {
+		lobbies = append(lobbies, a)
+	}
+	sort.Slice(lobbies, func(i, j int) bool {
+		if lobbies[i].Game != lobbies[j].Game {
+			return lobbies[i].Game < lobbies[j].Game
+		}
+		return lobbies[i].Address() < lobbies[j].Address()
+	})
+	return lobbies, nil
+}
@@ -0,0 +1,93 @@
+package lobby
+
+import (
+	"net"
+	"strconv"
+	"testing"
+)
+
+// freePort returns a UDP port nothing listens on at the moment.
+func freePort(t *testing.T) int {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestDiscoverOnLoopback(t *testing.T) {
+	target := net.JoinHostPort("127.0.0.1", strconv.Itoa(freePort(t)))
+	announcer, err := NewAnnouncer(Announcement{
+		Game:       "Freedoom",
+		Iwad:       "freedoom2.wad",
+		FilesHash:  "abc",
+		Port:       DefaultGamePort,
+		MaxPlayers: 4,
+	}, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer announcer.Stop()
+	lobbies, err := Discover(target, 2*announceInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lobbies) != 1 {
+		t.Fatalf("found %d games, want 1", len(lobbies))
+	}
+	a := lobbies[0]
+	if a.Game != "Freedoom" || a.Iwad != "freedoom2.wad" || a.FilesHash != "abc" {
+		t.Errorf("unexpected announcement %+v", a)
+	}
+	if a.MaxPlayers != 4 {
+		t.Errorf("max players = %d, want 4", a.MaxPlayers)
+	}
+	if want := net.JoinHostPort("127.0.0.1", strconv.Itoa(DefaultGamePort)); a.Address() != want {
+		t.Errorf("address = %s, want %s", a.Address(), want)
+	}
+}
+
+func TestDecodeAnnouncement(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"valid", `{"magic":"toby_launcher_lobby","version":1,"game":"Doom","port":5029}`, false},
+		{"foreign packet", `{"magic":"other","version":1,"game":"Doom","port":5029}`, true},
+		{"newer version", `{"magic":"toby_launcher_lobby","version":2,"game":"Doom","port":5029}`, true},
+		{"missing port", `{"magic":"toby_launcher_lobby","version":1,"game":"Doom"}`, true},
+		{"not json", `hello`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeAnnouncement([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"", "255.255.255.255:5030"},
+		{"192.168.1.255", "192.168.1.255:5030"},
+		{"127.0.0.1:6000", "127.0.0.1:6000"},
+	}
+	for _, tt := range tests {
+		addr, err := resolveTarget(tt.target)
+		if err != nil {
+			t.Errorf("resolveTarget(%q): %v", tt.target, err)
+			continue
+		}
+		if addr.String() != tt.want {
+			t.Errorf("resolveTarget(%q) = %s, want %s", tt.target, addr, tt.want)
+		}
+	}
+}
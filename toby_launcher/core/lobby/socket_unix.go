@@ -0,0 +1,23 @@
+//go:build !windows
+
+package lobby
+
+import (
+	"golang.org/x/sys/unix"
+	"syscall"
+)
+
+// reuseAddrControl lets several launchers on the same host listen on the discovery port.
+func reuseAddrControl(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		if sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1); sockErr != nil {
+			return
+		}
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package lobby
+
+import (
+	"golang.org/x/sys/windows"
+	"syscall"
+)
+
+// reuseAddrControl lets several launchers on the same host listen on the discovery port.
+func reuseAddrControl(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		sockErr = windows.SetsockoptInt(windows.Handle(fd), windows.SOL_SOCKET, windows.SO_REUSEADDR, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
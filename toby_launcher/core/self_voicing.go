@@ -0,0 +1,79 @@
+package core
+
+import (
+	"strings"
+	"toby_launcher/config"
+)
+
+// TextKind is the lowest verbosity level of the self-voicing UI at which the displayed text is spoken.
+// The values are the indices of the levels in config.VerbosityLevels.
+type TextKind int
+
+const (
+	// ImportantText is the name of the state, errors and help, spoken at the minimal level.
+	ImportantText TextKind = iota + 1
+	// PlainText is everything else that is displayed, such as menu options, spoken at the normal level.
+	PlainText
+	// HintText tells what to do and is spoken at the verbose level.
+	HintText
+)
+
+type screenText struct {
+	kind TextKind
+	text string
+}
+
+// screen collects the text displayed since the screen was last spoken.
+type screen struct {
+	texts []screenText
+	// lastState is the state the screen was last spoken in, its name is announced when it changes.
+	lastState State
+	isMuted   bool
+}
+
+func (s *screen) add(kind TextKind, text string) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", " "))
+	if text != "" {
+		s.texts = append(s.texts, screenText{kind: kind, text: text})
+	}
+}
+
+// SpeakScreen speaks the text displayed since the last call, as much of it as the self-voicing
+// verbosity allows. The name of the state is announced when the state has changed,
+// and at the verbose level its description too.
+func (ui *UiContext) SpeakScreen(ctx *AppContext, state State) {
+	texts := ui.screen.texts
+	ui.screen.texts = nil
+	level := TextKind(config.VerbosityLevel(ctx.Config.Tts.SelfVoicing))
+	if ui.screen.isMuted || level <= 0 {
+		ui.screen.isMuted = false
+		ui.screen.lastState = state
+		return
+	}
+	parts := make([]string, 0, len(texts)+2)
+	if state != ui.screen.lastState {
+		ui.screen.lastState = state
+		parts = append(parts, state.Name()+".")
+		if desc := state.Description(); level >= HintText && desc != "" && desc != (&BaseState{}).Description() {
+			parts = append(parts, desc)
+		}
+	}
+	for _, t := range texts {
+		if t.kind <= level {
+			parts = append(parts, t.text)
+		}
+	}
+	if len(parts) > 0 {
+		ui.TtsManager.Speak(strings.Join(parts, " "))
+	}
+}
+
+// MuteScreen keeps the screen displayed next from being spoken, so the speech stays interrupted.
+func (ui *UiContext) MuteScreen() {
+	ui.screen.isMuted = true
+}
+
+// RepeatScreen makes the screen displayed next be spoken from the name of its state.
+func (ui *UiContext) RepeatScreen() {
+	ui.screen.lastState = nil
+}
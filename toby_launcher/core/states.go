@@ -8,20 +8,27 @@ import (
 	"toby_launcher/utils"
 )
 
-type ExitState struct{ BaseState }
+// exitSpeechTimeout is the longest time in milliseconds the launcher waits for "Good bye!" to be spoken.
+const exitSpeechTimeout = 10000
+
+type ExitState struct {
+	BaseState
+	goodbyeId int
+}
 
 func (e *ExitState) Name() string {
 	return "exit"
 }
 
 func (e *ExitState) Display(_ *AppContext, ui *UiContext) {
-	ui.TtsManager.Speak("Good bye!")
+	e.goodbyeId = ui.TtsManager.Speak("Good bye!")
 	ui.DisplayText("Good bye!\r\n")
 }
 
-func (e *ExitState) Handle(ctx *AppContext, _ *UiContext, _ string) (State, error) {
+// Handle stops the launcher once "Good bye!" has been spoken.
+func (e *ExitState) Handle(ctx *AppContext, ui *UiContext, _ string) (State, error) {
 	ctx.AppIsRunning = false
-	return e, nil
+	return e, ui.TtsManager.WaitFor(e.goodbyeId, exitSpeechTimeout)
 }
 
 func (e *ExitState) RequiresInput() bool {
@@ -81,6 +88,8 @@ type MenuState struct {
 	options     []*MenuOption
 	optionsMap  map[int]*MenuOption
 	header      string
+	// defaultOption is selected when the input is empty.
+	defaultOption *MenuOption
 }
 
 func NewMenu(parentState State, options []*MenuOption, header string) *MenuState {
@@ -105,6 +114,12 @@ func NewMenu(parentState State, options []*MenuOption, header string) *MenuState
 	}
 }
 
+// SetDefault makes the option with the id selected by pressing "enter".
+func (m *MenuState) SetDefault(id int) *MenuState {
+	m.defaultOption = m.optionsMap[id]
+	return m
+}
+
 func (m *MenuState) Name() string {
 	if m.parentState != nil {
 		return m.parentState.Name()
@@ -125,10 +140,17 @@ func (m *MenuState) Display(ctx *AppContext, ui *UiContext) {
 		}
 		ui.DisplayText(fmt.Sprintf("%d. %s\r\n", option.Id, desc))
 	}
-	ui.DisplayText("Make your choice.\r\n")
+	if m.defaultOption != nil {
+		ui.DisplayHint(fmt.Sprintf("Make your choice, or press \"enter\" for item %d.\r\n", m.defaultOption.Id))
+		return
+	}
+	ui.DisplayHint("Make your choice.\r\n")
 }
 
 func (m *MenuState) Handle(ctx *AppContext, ui *UiContext, input string) (State, error) {
+	if input == "" && m.defaultOption != nil {
+		return m.defaultOption.NextState()
+	}
 	num, err := validation.ParseInt(input)
 	if err != nil {
 		return m, err
@@ -154,13 +176,13 @@ func (s OptionSwitcher) String() string {
 
 type SwitchOptionState struct {
 	BaseState
-	option       *bool
-	name         string
-	changeOption func()
+	name string
+	get  func() bool
+	set  func(bool)
 }
 
 func (s *SwitchOptionState) Init(ctx *AppContext, ui *UiContext) (State, error) {
-	if s.option == nil {
+	if s.get == nil || s.set == nil {
 		err := apperrors.New(apperrors.Err, "Option \"$option\" is not specified.", map[string]any{"option": s.name})
 		ui.DisplayError(err)
 		return ctx.GetPreviousState()
@@ -169,9 +191,9 @@ func (s *SwitchOptionState) Init(ctx *AppContext, ui *UiContext) (State, error)
 }
 
 func (s *SwitchOptionState) Handle(ctx *AppContext, ui *UiContext, input string) (State, error) {
-	switcher := OptionSwitcher(*s.option)
+	switcher := OptionSwitcher(s.get())
 	ui.DisplayText(fmt.Sprintf("%s is %vd.\r\n", s.name, !switcher))
-	*s.option = !bool(switcher)
+	s.set(!bool(switcher))
 	return ctx.GetPreviousState()
 }
 
@@ -183,14 +205,19 @@ func NewSwitchMenuOption(id int, name string, option *bool) *MenuOption {
 	if option == nil {
 		return nil
 	}
+	return NewToggleMenuOption(id, name, func() bool { return *option }, func(v bool) { *option = v })
+}
+
+// NewToggleMenuOption creates a menu option switching a value that is not addressable directly.
+func NewToggleMenuOption(id int, name string, get func() bool, set func(bool)) *MenuOption {
 	return &MenuOption{
 		Id:          id,
 		Description: "$action $option.",
 		Params: func() map[string]any {
-			return map[string]any{"action": !OptionSwitcher(*option), "option": name}
+			return map[string]any{"action": !OptionSwitcher(get()), "option": name}
 		},
 		NextState: func() (State, error) {
-			return &SwitchOptionState{option: option, name: name}, nil
+			return &SwitchOptionState{name: name, get: get, set: set}, nil
 		},
 	}
 }
@@ -0,0 +1,141 @@
+package tts
+
+import (
+	"time"
+	"toby_launcher/utils"
+)
+
+const (
+	// failureThreshold is the number of consecutive failures after which a synthesizer is replaced.
+	failureThreshold = 3
+	// restoreInterval is the first interval between the attempts to restore the preferred synthesizer.
+	restoreInterval = 30 * time.Second
+	// maxRestoreInterval limits the interval, which is doubled after every failed attempt.
+	maxRestoreInterval = 16 * time.Minute
+)
+
+// health counts the consecutive failures of every synthesizer.
+type health struct {
+	failures     map[string]int
+	lastRestore  time.Time
+	restoreDelay time.Duration
+	// restored is the synthesizer restored after a failure which has not spoken yet.
+	restored string
+}
+
+func newHealth() *health {
+	return &health{
+		failures:     make(map[string]int),
+		restoreDelay: restoreInterval,
+	}
+}
+
+// record records the result of speaking with the synthesizer and reports whether it works,
+// which is until it fails failureThreshold times in a row. A restored synthesizer is replaced
+// after its first failure, and the next attempt to restore it is made later.
+func (h *health) record(synthName string, err error) bool {
+	if err == nil {
+		h.failures[synthName] = 0
+		if h.restored == synthName {
+			h.restored = ""
+			h.restoreDelay = restoreInterval
+		}
+		return true
+	}
+	h.failures[synthName]++
+	if h.restored == synthName {
+		h.restored = ""
+		h.failures[synthName] = failureThreshold
+		h.backOff()
+	}
+	return h.failures[synthName] < failureThreshold
+}
+
+// backOff doubles the interval before the next attempt to restore the preferred synthesizer.
+func (h *health) backOff() {
+	h.restoreDelay = min(2*h.restoreDelay, maxRestoreInterval)
+}
+
+func (h *health) isFailing(synthName string) bool {
+	return h.failures[synthName] >= failureThreshold
+}
+
+func (h *health) reset(synthName string) {
+	delete(h.failures, synthName)
+	if h.restored == synthName {
+		h.restored = ""
+	}
+}
+
+// failover replaces the failing current synthesizer with the next working synthesizer in the order
+// and announces the switch. The synthesizers which do not speak are never switched to.
+// The configuration keeps the preferred synthesizer, which is restored later.
+func (m *TtsManager) failover() bool {
+	failed := m.currentSynthesizer.Name()
+	start := 0
+	for i, factory := range m.factories {
+		if factory.name == failed {
+			start = i + 1
+			break
+		}
+	}
+	count := len(m.factories)
+	for i := 0; i < count; i++ {
+		factory := m.factories[(start+i)%count]
+		name := factory.name
+		if name == failed || factory.isExplicit || m.health.isFailing(name) {
+			continue
+		}
+		if err := m.setSynthesizer(name); err != nil {
+			m.logger.DebugError(err)
+			m.health.failures[name] = failureThreshold
+			continue
+		}
+		m.health.lastRestore = time.Now()
+		m.announce("The speech synthesizer $failed has failed, switched to $synthesizer.", map[string]any{"failed": failed, "synthesizer": name})
+		return true
+	}
+	return false
+}
+
+// tryRestorePreferred switches back to the synthesizer preferred by the user once in the restore delay.
+// Creating the synthesizer does not prove that it speaks, so it is replaced again after its first failure,
+// and the delay is doubled after every failed attempt.
+func (m *TtsManager) tryRestorePreferred() {
+	preferred := m.config.SynthesizerName
+	if preferred == "" || m.currentSynthesizer.Name() == preferred || time.Since(m.health.lastRestore) < m.health.restoreDelay {
+		return
+	}
+	m.health.lastRestore = time.Now()
+	if err := m.setSynthesizer(preferred); err != nil {
+		m.logger.DebugError(err)
+		m.health.backOff()
+		return
+	}
+	m.health.reset(preferred)
+	m.health.restored = preferred
+	m.announce("The speech synthesizer $synthesizer has been restored.", map[string]any{"synthesizer": preferred})
+}
+
+// announce keeps the message to be queued after the current phrase once the mutex is unlocked,
+// as queuing may call the callbacks of the dropped phrases, which may call the manager.
+// It is called with the mutex locked.
+func (m *TtsManager) announce(msg string, params map[string]any) {
+	text := utils.SubstituteParams(msg, params)
+	m.logger.Printf("%s\r\n", text)
+	phrase := m.newPhrase(text, 0, 0)
+	phrase.Priority = UiPriority
+	phrase.Policy = EnqueuePolicy
+	m.announcements = append(m.announcements, phrase)
+}
+
+// pushAnnouncements queues the announcements kept by announce. It is called with the mutex unlocked.
+func (m *TtsManager) pushAnnouncements() {
+	m.mu.Lock()
+	announcements := m.announcements
+	m.announcements = nil
+	m.mu.Unlock()
+	for _, phrase := range announcements {
+		m.queue.push(phrase)
+	}
+}
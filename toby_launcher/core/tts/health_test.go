@@ -0,0 +1,150 @@
+package tts
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"toby_launcher/config"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Printf(format string, v ...any)      {}
+func (nopLogger) Error(err error)                     {}
+func (nopLogger) InfoPrintf(format string, v ...any)  {}
+func (nopLogger) DebugPrintf(format string, v ...any) {}
+func (nopLogger) DebugError(err error)                {}
+func (nopLogger) Release()                            {}
+
+// newHealthTestManager returns a manager preferring the first synthesizer and speaking with the last one,
+// as if it had failed over to it. The announcements are queued to the fake speaker.
+func newHealthTestManager(t *testing.T, synths ...*fakeSynthesizer) (*TtsManager, *fakeSpeaker) {
+	t.Helper()
+	s := newFakeSpeaker()
+	m := &TtsManager{
+		logger:             nopLogger{},
+		config:             &config.TtsConfig{SynthesizerName: synths[0].name},
+		health:             newHealth(),
+		currentSynthesizer: synths[len(synths)-1],
+		queue:              newSpeechQueue(s, 10),
+	}
+	t.Cleanup(m.queue.stop)
+	for i, synth := range synths {
+		synth := synth
+		m.factories = append(m.factories, factory{
+			name:     synth.name,
+			create:   func() (SpeechSynthesizer, error) { return synth, nil },
+			priority: i,
+		})
+	}
+	return m, s
+}
+
+func TestFailedRestoreBacksOff(t *testing.T) {
+	preferred := &fakeSynthesizer{name: "preferred", err: errors.New("no audio")}
+	backup := &fakeSynthesizer{name: "backup"}
+	m, _ := newHealthTestManager(t, preferred, backup)
+	speak := func(text string, sinceRestore time.Duration) {
+		t.Helper()
+		m.health.lastRestore = time.Now().Add(-sinceRestore)
+		if err := m.speakNow(&Phrase{Text: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The restored synthesizer creates fine but fails to speak, so the phrase is spoken by the backup at once.
+	speak("first", restoreInterval)
+	if m.currentSynthesizer != backup || len(backup.spoken) != 1 {
+		t.Fatalf("current = %s, backup spoke %q", m.currentSynthesizer.Name(), backup.spoken)
+	}
+	if m.health.restoreDelay != 2*restoreInterval {
+		t.Errorf("restore delay = %s, want %s", m.health.restoreDelay, 2*restoreInterval)
+	}
+	speak("second", restoreInterval)
+	if m.currentSynthesizer != backup {
+		t.Error("the preferred synthesizer was restored before the doubled delay")
+	}
+	speak("third", 2*restoreInterval)
+	if m.health.restoreDelay != 4*restoreInterval {
+		t.Errorf("restore delay = %s, want %s", m.health.restoreDelay, 4*restoreInterval)
+	}
+	for i := 0; i < 10; i++ {
+		m.health.backOff()
+	}
+	if m.health.restoreDelay != maxRestoreInterval {
+		t.Errorf("restore delay = %s, want at most %s", m.health.restoreDelay, maxRestoreInterval)
+	}
+	// Once the preferred synthesizer speaks again, the delay starts over.
+	preferred.err = nil
+	speak("fourth", maxRestoreInterval)
+	if m.currentSynthesizer != preferred || len(preferred.spoken) != 1 {
+		t.Fatalf("current = %s, preferred spoke %q", m.currentSynthesizer.Name(), preferred.spoken)
+	}
+	if m.health.restoreDelay != restoreInterval {
+		t.Errorf("restore delay = %s, want %s", m.health.restoreDelay, restoreInterval)
+	}
+	if len(backup.spoken) != 3 {
+		t.Errorf("backup spoke %q, want the first three phrases", backup.spoken)
+	}
+}
+
+func TestOnDoneMayCallManagerWhenAnnouncing(t *testing.T) {
+	failing := &fakeSynthesizer{name: "failing", err: errors.New("no audio")}
+	backup := &fakeSynthesizer{name: "backup"}
+	m, s := newHealthTestManager(t, backup, failing)
+	m.config.SynthesizerName = ""
+	m.queue.maxLength = 1
+	m.queue.push(&Phrase{Id: 1, Text: "current", Priority: NormalPriority})
+	waitStarted(t, s, 1)
+	// The queued phrase is dropped for the announcement of the failover, and its callback calls the manager.
+	dropped := make(chan Capabilities, 1)
+	m.queue.push(&Phrase{Id: 2, Text: "queued", Priority: NormalPriority, OnDone: func(PhraseStatus) {
+		dropped <- m.Capabilities()
+	}})
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < failureThreshold-1; i++ {
+			m.speakNow(&Phrase{Text: "failing"})
+		}
+		done <- m.speakNow(&Phrase{Text: "spoken by the backup"})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("speaking deadlocked when the announcement dropped a phrase")
+	}
+	select {
+	case <-dropped:
+	case <-time.After(time.Second):
+		t.Fatal("the callback of the dropped phrase was not called")
+	}
+	if m.currentSynthesizer != backup {
+		t.Errorf("current = %s, want backup", m.currentSynthesizer.Name())
+	}
+}
+
+func TestRescanDoesNotHoldLock(t *testing.T) {
+	probed := &fakeSynthesizer{name: "probed"}
+	current := &fakeSynthesizer{name: "current"}
+	m, _ := newHealthTestManager(t, probed, current)
+	// The probed synthesizer takes long to create, meanwhile the manager is used by the queue.
+	m.factories[0].create = func() (SpeechSynthesizer, error) {
+		m.Capabilities()
+		return probed, nil
+	}
+	done := make(chan []EngineStatus, 1)
+	go func() {
+		m.EngineStatuses()
+		done <- m.Rescan()
+	}()
+	select {
+	case statuses := <-done:
+		if len(statuses) != 2 || !statuses[0].IsAvailable() || !statuses[1].IsAvailable() {
+			t.Errorf("statuses = %+v", statuses)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("probing the synthesizers deadlocked on the lock of the manager")
+	}
+}
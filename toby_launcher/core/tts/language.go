@@ -0,0 +1,175 @@
+package tts
+
+import (
+	"regexp"
+	"toby_launcher/config"
+	"unicode"
+	"unicode/utf8"
+)
+
+// languageRule is a compiled rule of the language detection.
+type languageRule struct {
+	script   *unicode.RangeTable
+	pattern  *regexp.Regexp
+	language string
+}
+
+func compileLanguageRules(detection *config.LanguageDetection) []languageRule {
+	if detection == nil {
+		return nil
+	}
+	rules := make([]languageRule, 0, len(detection.Rules))
+	for _, r := range detection.Rules {
+		rule := languageRule{language: r.Language}
+		if r.Script != "" {
+			rule.script = unicode.Scripts[r.Script]
+		}
+		if r.Pattern != "" {
+			rule.pattern = regexp.MustCompile(r.Pattern)
+		}
+		if rule.script != nil || rule.pattern != nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// languageSpan is a part of a text in one language. The language is empty if it is unknown.
+type languageSpan struct {
+	start, end int
+	language   string
+}
+
+// detectLanguages splits the text into the spans of its languages. The matches of the patterns
+// have the language of their rule. Other words have the language of the script most of their letters
+// are written in, and the spaces and punctuation between the words belong to the preceding word.
+func detectLanguages(text string, rules []languageRule) []languageSpan {
+	// languages holds the language of every byte of the text matched by a pattern.
+	languages := make([]string, len(text))
+	for _, rule := range rules {
+		if rule.pattern == nil {
+			continue
+		}
+		for _, match := range rule.pattern.FindAllStringIndex(text, -1) {
+			for i := match[0]; i < match[1]; i++ {
+				if languages[i] == "" {
+					languages[i] = rule.language
+				}
+			}
+		}
+	}
+	spans := make([]languageSpan, 0, 2)
+	add := func(start, end int, language string) {
+		last := len(spans) - 1
+		switch {
+		case last >= 0 && (spans[last].language == language || language == ""):
+			spans[last].end = end
+		case last >= 0 && spans[last].language == "":
+			// The text before the first word of a known language belongs to it.
+			spans[last].end = end
+			spans[last].language = language
+		default:
+			spans = append(spans, languageSpan{start: start, end: end, language: language})
+		}
+	}
+	for i := 0; i < len(text); {
+		if languages[i] != "" {
+			end := i
+			for end < len(text) && languages[end] == languages[i] {
+				end++
+			}
+			add(i, end, languages[i])
+			i = end
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !unicode.IsLetter(r) {
+			add(i, i+size, "")
+			i += size
+			continue
+		}
+		end := i
+		counts := make(map[string]int)
+		for end < len(text) && languages[end] == "" {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				break
+			}
+			if language := scriptLanguage(r, rules); language != "" {
+				counts[language]++
+			}
+			end += size
+		}
+		language := ""
+		for l, count := range counts {
+			if count > counts[language] || (count == counts[language] && l < language) {
+				language = l
+			}
+		}
+		add(i, end, language)
+		i = end
+	}
+	return spans
+}
+
+func scriptLanguage(r rune, rules []languageRule) string {
+	for _, rule := range rules {
+		if rule.script != nil && unicode.Is(rule.script, r) {
+			return rule.language
+		}
+	}
+	return ""
+}
+
+// splitByLanguage returns the parts of the phrase in different languages, each with the voice
+// of its language for the current synthesizer or with the selected voice if the language has none.
+// A phrase with its own voice, language or markup is not split, and the adjacent parts
+// spoken by the same voice are joined.
+// It is called with the mutex locked.
+func (m *TtsManager) splitByLanguage(phrase *Phrase) []*Phrase {
+	detection := m.config.LanguageDetection
+	if detection == nil || !detection.Enabled || phrase.Voice != "" || m.currentSynthesizer == nil ||
+		!m.currentSynthesizer.Capabilities().Has(VoiceCapability) {
+		return []*Phrase{phrase}
+	}
+	engine := m.currentSynthesizer.Name()
+	selectedVoice := m.currentSynthesizer.GetVoice()
+	voiceOf := func(language string) string {
+		if voice := detection.Voice(language, engine); voice != "" {
+			return voice
+		}
+		return selectedVoice
+	}
+	if phrase.Language != "" || phrase.Markup {
+		part := *phrase
+		part.Voice = voiceOf(phrase.Language)
+		return []*Phrase{&part}
+	}
+	parts := make([]*Phrase, 0, 2)
+	for _, span := range detectLanguages(phrase.Text, m.languageRules) {
+		voice := voiceOf(span.language)
+		if last := len(parts) - 1; last >= 0 && parts[last].Voice == voice {
+			parts[last].Text += phrase.Text[span.start:span.end]
+			if parts[last].Language != span.language {
+				parts[last].Language = ""
+			}
+			continue
+		}
+		part := *phrase
+		part.Text = phrase.Text[span.start:span.end]
+		part.Voice = voice
+		part.Language = span.language
+		parts = append(parts, &part)
+	}
+	if len(parts) == 0 {
+		return []*Phrase{phrase}
+	}
+	return parts
+}
+
+// EnableLanguageDetection turns the switching of the voice by the language of the text on or off.
+func (m *TtsManager) EnableLanguageDetection(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config.LanguageDetection.Enabled = enabled
+}
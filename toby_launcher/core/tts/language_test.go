@@ -0,0 +1,115 @@
+package tts
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"toby_launcher/config"
+)
+
+// voiceSynthesizer is a fake synthesizer with voices, speaking with the voice "default".
+type voiceSynthesizer struct {
+	fakeSynthesizer
+	capabilities Capabilities
+}
+
+func (s *voiceSynthesizer) Capabilities() Capabilities { return s.capabilities }
+
+func (s *voiceSynthesizer) GetVoice() string { return "default" }
+
+var mapCodeRule = config.LanguageRule{Pattern: `E\dM\d`, Language: "ru"}
+
+func TestDetectLanguages(t *testing.T) {
+	rules := compileLanguageRules(&config.LanguageDetection{
+		Rules: append(append([]config.LanguageRule(nil), config.DefaultLanguageRules...), mapCodeRule),
+	})
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello world", []string{"en:Hello world"}},
+		{"Привет, world", []string{"ru:Привет, ", "en:world"}},
+		{"You got the дробовик!", []string{"en:You got the ", "ru:дробовик!"}},
+		// The punctuation and spaces belong to the preceding word.
+		{"Стоп! Go... Да.", []string{"ru:Стоп! ", "en:Go... ", "ru:Да."}},
+		// The text before the first word belongs to it.
+		{"  123 Hello", []string{"en:  123 Hello"}},
+		// A word is in the language of most of its letters.
+		{"Дoom", []string{"en:Дoom"}},
+		{"Дoмм", []string{"ru:Дoмм"}},
+		{"Дooм", []string{"en:Дooм"}},
+		{"Doom2", []string{"en:Doom2"}},
+		// The patterns take precedence over the scripts.
+		{"Entering E1M1 now", []string{"en:Entering ", "ru:E1M1 ", "en:now"}},
+		{"Карта E1M1", []string{"ru:Карта E1M1"}},
+		{"...", []string{":..."}},
+		{"", nil},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, span := range detectLanguages(test.text, rules) {
+			got = append(got, span.language+":"+test.text[span.start:span.end])
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("detectLanguages(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+	if spans := detectLanguages("Привет world", nil); len(spans) != 1 || spans[0].language != "" {
+		t.Errorf("detectLanguages without rules = %+v, want one span of an unknown language", spans)
+	}
+}
+
+func TestSplitByLanguage(t *testing.T) {
+	detection := &config.LanguageDetection{
+		Enabled: true,
+		Rules: append(append([]config.LanguageRule(nil), config.DefaultLanguageRules...),
+			config.LanguageRule{Pattern: "Guten Tag", Language: "de"}),
+		Voices: map[string]map[string]string{
+			"ru": {"fake": "russian", "other": "ru"},
+			"de": {"other": "de"},
+		},
+	}
+	synth := &voiceSynthesizer{fakeSynthesizer: fakeSynthesizer{name: "fake"}, capabilities: VoiceCapability}
+	m := &TtsManager{
+		config:             &config.TtsConfig{LanguageDetection: detection},
+		currentSynthesizer: synth,
+		languageRules:      compileLanguageRules(detection),
+	}
+	tests := []struct {
+		phrase Phrase
+		want   []string
+	}{
+		{Phrase{Text: "Hello world"}, []string{"default/en:Hello world"}},
+		{Phrase{Text: "Привет, world"}, []string{"russian/ru:Привет, ", "default/en:world"}},
+		{Phrase{Text: "You got the дробовик! Go"}, []string{"default/en:You got the ", "russian/ru:дробовик! ", "default/en:Go"}},
+		// German has no voice for the engine, so it is spoken by the selected voice together with English.
+		{Phrase{Text: "Hello, Guten Tag friend"}, []string{"default/:Hello, Guten Tag friend"}},
+		{Phrase{Text: "Привет Guten Tag"}, []string{"russian/ru:Привет ", "default/de:Guten Tag"}},
+		// The phrases with their own voice, language or markup are not split.
+		{Phrase{Text: "Привет world", Voice: "chosen"}, []string{"chosen/:Привет world"}},
+		{Phrase{Text: "Привет world", Language: "ru"}, []string{"russian/ru:Привет world"}},
+		{Phrase{Text: "Привет <emphasis>world</emphasis>", Markup: true}, []string{"default/:Привет <emphasis>world</emphasis>"}},
+	}
+	split := func(phrase Phrase) []string {
+		var parts []string
+		for _, part := range m.splitByLanguage(&phrase) {
+			parts = append(parts, part.Voice+"/"+part.Language+":"+part.Text)
+		}
+		return parts
+	}
+	for _, test := range tests {
+		if got := split(test.phrase); strings.Join(got, "|") != strings.Join(test.want, "|") {
+			t.Errorf("splitByLanguage(%q) = %q, want %q", test.phrase.Text, got, test.want)
+		}
+	}
+	// The phrases are not split while the detection is disabled or the synthesizer has no voices.
+	detection.Enabled = false
+	if got := split(Phrase{Text: "Привет world"}); len(got) != 1 || got[0] != "/:Привет world" {
+		t.Errorf("split with the detection disabled = %q", got)
+	}
+	detection.Enabled = true
+	synth.capabilities = RateCapability
+	if got := split(Phrase{Text: "Привет world"}); len(got) != 1 || got[0] != "/:Привет world" {
+		t.Errorf("split by a synthesizer without voices = %q", got)
+	}
+}
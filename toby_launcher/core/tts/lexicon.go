@@ -0,0 +1,194 @@
+package tts
+
+import (
+	"regexp"
+	"strings"
+	"toby_launcher/config"
+	"unicode"
+	"unicode/utf8"
+)
+
+// lexiconRule is a compiled entry of the lexicon.
+type lexiconRule struct {
+	pattern  *regexp.Regexp
+	spoken   string
+	engine   string
+	language string
+	// wordStart and wordEnd require word boundaries at the edges of the match that are letters or digits.
+	wordStart bool
+	wordEnd   bool
+}
+
+func compileLexicon(lexicon *config.Lexicon) []lexiconRule {
+	if lexicon == nil {
+		return nil
+	}
+	rules := make([]lexiconRule, 0, len(lexicon.Entries))
+	for _, entry := range lexicon.Entries {
+		if entry.Word == "" {
+			continue
+		}
+		expr := regexp.QuoteMeta(entry.Word)
+		if !entry.CaseSensitive {
+			expr = "(?i)" + expr
+		}
+		first, _ := utf8.DecodeRuneInString(entry.Word)
+		last, _ := utf8.DecodeLastRuneInString(entry.Word)
+		rules = append(rules, lexiconRule{
+			pattern:   regexp.MustCompile(expr),
+			spoken:    entry.Spoken,
+			engine:    entry.Engine,
+			language:  entry.Language,
+			wordStart: !entry.Partial && isWordRune(first),
+			wordEnd:   !entry.Partial && isWordRune(last),
+		})
+	}
+	return rules
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// appliesTo reports whether the rule is used with the engine and the language of the voice.
+// A rule limited to a language is not used while the language of the voice is unknown.
+func (r *lexiconRule) appliesTo(engine, language string) bool {
+	if r.engine != "" && r.engine != engine {
+		return false
+	}
+	return r.language == "" || matchesLanguage(language, r.language)
+}
+
+// matchesLanguage reports whether the language, such as "en-US", is the wanted language or its variant.
+func matchesLanguage(language, wanted string) bool {
+	language = strings.ToLower(strings.ReplaceAll(language, "_", "-"))
+	wanted = strings.ToLower(strings.ReplaceAll(wanted, "_", "-"))
+	return language == wanted || strings.HasPrefix(language, wanted+"-")
+}
+
+// lexiconPart is a part of a text, either the spoken form of a word of the lexicon or the text around them.
+type lexiconPart struct {
+	text   string
+	spoken bool
+}
+
+// split splits the text into the spoken forms of the matches of the rule and the text around them.
+func (r *lexiconRule) split(text string) []lexiconPart {
+	parts := make([]lexiconPart, 0, 1)
+	last := 0
+	for _, match := range r.pattern.FindAllStringIndex(text, -1) {
+		if r.wordStart {
+			if before, _ := utf8.DecodeLastRuneInString(text[:match[0]]); isWordRune(before) {
+				continue
+			}
+		}
+		if r.wordEnd {
+			if after, _ := utf8.DecodeRuneInString(text[match[1]:]); isWordRune(after) {
+				continue
+			}
+		}
+		if match[0] > last {
+			parts = append(parts, lexiconPart{text: text[last:match[0]]})
+		}
+		parts = append(parts, lexiconPart{text: r.spoken, spoken: true})
+		last = match[1]
+	}
+	if last < len(text) {
+		parts = append(parts, lexiconPart{text: text[last:]})
+	}
+	return parts
+}
+
+// UpdateLexicon compiles the lexicon of the configuration again after its entries have been changed.
+func (m *TtsManager) UpdateLexicon() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lexicon = compileLexicon(m.config.Lexicon)
+}
+
+// pronounce returns the phrase with the words of the lexicon replaced by their spoken forms and normalized.
+// The markup of the phrase is rendered for the current synthesizer.
+// It is called with the mutex locked.
+func (m *TtsManager) pronounce(phrase *Phrase) *Phrase {
+	if phrase.Markup {
+		return m.render(phrase)
+	}
+	if phrase.Verbatim || (len(m.lexicon) == 0 && len(m.normalizers) == 0) {
+		return phrase
+	}
+	text := m.pronounceText(phrase.Text, phrase.Language)
+	if text == phrase.Text {
+		return phrase
+	}
+	pronounced := *phrase
+	pronounced.Text = text
+	return &pronounced
+}
+
+// pronounceText replaces the words of the lexicon in the text by their spoken forms and normalizes
+// the text around them. The lexicon comes first, as the normalization changes the case of the words
+// its case-sensitive entries are written in, and the spoken forms are kept as they are written.
+func (m *TtsManager) pronounceText(text, language string) string {
+	parts := m.applyLexicon(text, language)
+	if len(parts) == 1 && !parts[0].spoken {
+		return m.normalize(text)
+	}
+	var result strings.Builder
+	for _, part := range parts {
+		if part.spoken {
+			result.WriteString(part.text)
+		} else {
+			result.WriteString(m.normalize(part.text))
+		}
+	}
+	return result.String()
+}
+
+// applyLexicon splits the text at the words of the lexicon in the language. If the language is empty,
+// the language of the voice is used. The spoken forms are not matched by the following rules.
+func (m *TtsManager) applyLexicon(text, language string) []lexiconPart {
+	parts := []lexiconPart{{text: text}}
+	engine := m.currentSynthesizer.Name()
+	for i := range m.lexicon {
+		rule := &m.lexicon[i]
+		if rule.language != "" && language == "" {
+			if !m.isLanguageKnown {
+				m.voiceLanguage = m.findVoiceLanguage()
+				m.isLanguageKnown = true
+			}
+			language = m.voiceLanguage
+		}
+		if !rule.appliesTo(engine, language) {
+			continue
+		}
+		split := make([]lexiconPart, 0, len(parts))
+		for _, part := range parts {
+			if part.spoken {
+				split = append(split, part)
+			} else {
+				split = append(split, rule.split(part.text)...)
+			}
+		}
+		parts = split
+	}
+	return parts
+}
+
+// findVoiceLanguage returns the language of the voice of the current synthesizer if it lists its voices.
+func (m *TtsManager) findVoiceLanguage() string {
+	voice := m.currentSynthesizer.GetVoice()
+	if voice == "" {
+		return ""
+	}
+	voices, err := m.currentSynthesizer.Voices()
+	if err != nil {
+		m.logger.DebugError(err)
+		return ""
+	}
+	for _, v := range voices {
+		if v.Id == voice {
+			return v.Language
+		}
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package tts
+
+import (
+	"testing"
+	"toby_launcher/config"
+)
+
+// fakeSynthesizer records the phrases it speaks and fails to speak while err is set.
+type fakeSynthesizer struct {
+	BaseSynthesizer
+	name   string
+	err    error
+	spoken []string
+}
+
+func (s *fakeSynthesizer) Name() string { return s.name }
+
+func (s *fakeSynthesizer) CreateNew() (SpeechSynthesizer, error) {
+	return &fakeSynthesizer{name: s.name, err: s.err}, nil
+}
+
+func (s *fakeSynthesizer) Release() {}
+
+func (s *fakeSynthesizer) Speak(phrase *Phrase) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.spoken = append(s.spoken, phrase.Text)
+	return nil
+}
+
+func (s *fakeSynthesizer) Stop() error { return nil }
+
+func (s *fakeSynthesizer) IsSpeaking() (bool, error) { return false, nil }
+
+func (s *fakeSynthesizer) SetSpeechRate(rate int) error { return nil }
+
+func (s *fakeSynthesizer) GetSpeechRate() int { return 0 }
+
+func TestPronounceAppliesLexiconBeforeNormalization(t *testing.T) {
+	lexicon := &config.Lexicon{Entries: []config.LexiconEntry{
+		{Word: "Cacodemon", Spoken: "Cako demon"},
+		{Word: "BFG", Spoken: "B F G", CaseSensitive: true},
+		{Word: "UAC", Spoken: "U A C", CaseSensitive: true},
+		{Word: "G", Spoken: "gee", CaseSensitive: true},
+	}}
+	m := &TtsManager{
+		currentSynthesizer: &fakeSynthesizer{name: "fake"},
+		lexicon:            compileLexicon(lexicon),
+		normalizers:        createNormalizers(config.NewNormalization()),
+	}
+	tests := []struct {
+		text string
+		want string
+	}{
+		// The shouted words are lowered, the case-sensitive words of the lexicon are matched before.
+		{"PICKED UP THE BFG!!!", "picked up the B F G!"},
+		{"WELCOME TO THE UAC BASE", "welcome to the U A C base"},
+		{"picked up the bfg", "picked up the bfg"},
+		{"CACODEMON at 007", "Cako demon at 7"},
+		// The spoken forms are neither normalized nor matched by the following entries.
+		{"BFG +25", "B F G plus 25"},
+		{"E1M1", "episode 1, map 1"},
+	}
+	for _, test := range tests {
+		if got := m.pronounce(&Phrase{Text: test.text}).Text; got != test.want {
+			t.Errorf("pronounce(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package tts
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The text of a phrase with Markup set is a subset of SSML without the speak element:
+//
+//	<emphasis>text</emphasis> stresses the text,
+//	<break time="500ms"/> pauses the speech, the time is in milliseconds or seconds,
+//	<say-as interpret-as="characters">text</say-as> spells the text out,
+//	<lang xml:lang="de">text</lang> speaks the text in another language.
+//
+// The text outside the elements is escaped as in XML, so "&", "<" and ">" are written as
+// "&amp;", "&lt;" and "&gt;". Unknown elements are ignored, their text is spoken.
+// Synthesizers with MarkupCapability receive the markup as an SSML fragment,
+// the other synthesizers and the outputs receive the text with the markup stripped.
+
+// defaultBreak is the pause in milliseconds of a break without the time.
+const defaultBreak = 500
+
+// markupSegment is a run of text with the same markup, or a break if the pause is set.
+type markupSegment struct {
+	text     string
+	emphasis bool
+	spell    bool
+	language string
+	pause    int
+}
+
+type markupElement struct {
+	emphasis bool
+	spell    bool
+	language string
+}
+
+func parseMarkup(text string) ([]markupSegment, error) {
+	decoder := xml.NewDecoder(strings.NewReader("<speak>" + text + "</speak>"))
+	segments := make([]markupSegment, 0, 4)
+	stack := make([]markupElement, 0, 4)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return segments, nil
+			}
+			return nil, err
+		}
+		current := markupElement{}
+		if len(stack) > 0 {
+			current = stack[len(stack)-1]
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "emphasis":
+				current.emphasis = true
+			case "say-as":
+				if attr(t, "interpret-as") == "characters" {
+					current.spell = true
+				}
+			case "lang", "voice":
+				if language := attr(t, "lang"); language != "" {
+					current.language = language
+				}
+			case "break":
+				pause, err := parseBreakTime(attr(t, "time"))
+				if err != nil {
+					return nil, err
+				}
+				segments = append(segments, markupSegment{pause: pause})
+			}
+			stack = append(stack, current)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(t) == 0 {
+				continue
+			}
+			last := len(segments) - 1
+			if last >= 0 && segments[last].pause == 0 && segments[last].emphasis == current.emphasis &&
+				segments[last].spell == current.spell && segments[last].language == current.language {
+				segments[last].text += string(t)
+				continue
+			}
+			segments = append(segments, markupSegment{
+				text:     string(t),
+				emphasis: current.emphasis,
+				spell:    current.spell,
+				language: current.language,
+			})
+		}
+	}
+}
+
+func attr(element xml.StartElement, name string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// parseBreakTime parses the time of a break such as "250ms" or "1.5s" to milliseconds.
+func parseBreakTime(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultBreak, nil
+	}
+	multiplier := 1.0
+	number := strings.TrimSuffix(value, "ms")
+	if number == value {
+		number = strings.TrimSuffix(value, "s")
+		multiplier = 1000
+	}
+	seconds, err := strconv.ParseFloat(number, 64)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid break time %q", value)
+	}
+	return max(1, int(seconds*multiplier)), nil
+}
+
+var markupEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// EscapeMarkup escapes the plain text, so it can be a part of the markup.
+func EscapeMarkup(text string) string {
+	return markupEscaper.Replace(text)
+}
+
+var (
+	markupTag       = regexp.MustCompile(`<[^>]*>`)
+	markupUnescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'")
+)
+
+// StripMarkup returns the text of the markup as it would be spoken without the markup,
+// with the spelled text split into characters. Invalid markup is stripped of anything that looks like a tag.
+func StripMarkup(text string) string {
+	segments, err := parseMarkup(text)
+	if err != nil {
+		text = markupUnescaper.Replace(markupTag.ReplaceAllString(text, " "))
+		return strings.Join(strings.Fields(text), " ")
+	}
+	return renderPlain(segments)
+}
+
+// SsmlText returns the text of the phrase as an SSML fragment for the synthesizers with MarkupCapability,
+// which wrap it in the speak element. The text of a phrase without markup is escaped.
+func SsmlText(phrase *Phrase) string {
+	if phrase.Markup {
+		return phrase.Text
+	}
+	return EscapeMarkup(phrase.Text)
+}
+
+func renderSsml(segments []markupSegment) string {
+	var result strings.Builder
+	for _, s := range segments {
+		if s.pause > 0 {
+			fmt.Fprintf(&result, `<break time="%dms"/>`, s.pause)
+			continue
+		}
+		if s.text == "" {
+			continue
+		}
+		text := EscapeMarkup(s.text)
+		if s.spell {
+			text = `<say-as interpret-as="characters">` + text + `</say-as>`
+		}
+		if s.emphasis {
+			text = "<emphasis>" + text + "</emphasis>"
+		}
+		if s.language != "" {
+			// The voice element switches the language in SSML 1.0, which SAPI and espeak understand.
+			text = fmt.Sprintf(`<voice xml:lang="%s">%s</voice>`, EscapeMarkup(s.language), text)
+		}
+		result.WriteString(text)
+	}
+	return result.String()
+}
+
+// renderPlain joins the text of the segments. Spelled text is split into characters
+// and a break becomes a comma, which most synthesizers pause at.
+func renderPlain(segments []markupSegment) string {
+	text := ""
+	for _, s := range segments {
+		switch {
+		case s.pause > 0:
+			text = strings.TrimRightFunc(text, unicode.IsSpace)
+			if last, _ := utf8.DecodeLastRuneInString(text); text != "" && !unicode.IsPunct(last) {
+				text += ","
+			}
+			text += " "
+		case s.spell:
+			text += spellOut(s.text)
+		default:
+			text += s.text
+		}
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func spellOut(text string) string {
+	letters := make([]string, 0, len(text))
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			letters = append(letters, string(r))
+		}
+	}
+	return " " + strings.Join(letters, " ") + " "
+}
+
+// render returns the phrase with the text of its markup normalized and the lexicon applied, converted to
+// an SSML fragment if the current synthesizer supports it, or stripped of the markup otherwise.
+// It is called with the mutex locked.
+func (m *TtsManager) render(phrase *Phrase) *Phrase {
+	segments, err := parseMarkup(phrase.Text)
+	rendered := *phrase
+	if err != nil {
+		m.logger.DebugError(fmt.Errorf("invalid speech markup %q: %w", phrase.Text, err))
+		rendered.Text = StripMarkup(phrase.Text)
+		rendered.Markup = false
+		return m.pronounce(&rendered)
+	}
+	if !phrase.Verbatim {
+		for i := range segments {
+			if !segments[i].spell {
+				language := segments[i].language
+				if language == "" {
+					language = phrase.Language
+				}
+				segments[i].text = m.pronounceText(segments[i].text, language)
+			}
+		}
+	}
+	if m.currentSynthesizer.Capabilities().Has(MarkupCapability) {
+		rendered.Text = renderSsml(segments)
+	} else {
+		rendered.Text = renderPlain(segments)
+		rendered.Markup = false
+	}
+	return &rendered
+}
@@ -0,0 +1,147 @@
+package tts
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseMarkup(t *testing.T) {
+	tests := []struct {
+		markup string
+		want   []markupSegment
+	}{
+		{"plain text", []markupSegment{{text: "plain text"}}},
+		{"a &amp; b", []markupSegment{{text: "a & b"}}},
+		{`a <emphasis>b <say-as interpret-as="characters">HP</say-as></emphasis> c`, []markupSegment{
+			{text: "a "},
+			{text: "b ", emphasis: true},
+			{text: "HP", emphasis: true, spell: true},
+			{text: " c"},
+		}},
+		{`<lang xml:lang="de">Guten <emphasis>Tag</emphasis></lang>!`, []markupSegment{
+			{text: "Guten ", language: "de"},
+			{text: "Tag", emphasis: true, language: "de"},
+			{text: "!"},
+		}},
+		{`<voice xml:lang="ru">Привет</voice>`, []markupSegment{{text: "Привет", language: "ru"}}},
+		{`<say-as interpret-as="date">today</say-as>`, []markupSegment{{text: "today"}}},
+		{`Wait<break time="1.5s"/>go`, []markupSegment{{text: "Wait"}, {pause: 1500}, {text: "go"}}},
+		{`<break/>`, []markupSegment{{pause: defaultBreak}}},
+		{`<unknown>text</unknown> more`, []markupSegment{{text: "text more"}}},
+	}
+	for _, test := range tests {
+		got, err := parseMarkup(test.markup)
+		if err != nil {
+			t.Errorf("parseMarkup(%q) failed: %v", test.markup, err)
+			continue
+		}
+		if fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", test.want) {
+			t.Errorf("parseMarkup(%q) = %+v, want %+v", test.markup, got, test.want)
+		}
+	}
+}
+
+func TestParseInvalidMarkup(t *testing.T) {
+	for _, markup := range []string{
+		"<emphasis>not closed",
+		"a < b",
+		"</emphasis>",
+		"Tom & Jerry",
+		`<break time="soon"/>`,
+	} {
+		if segments, err := parseMarkup(markup); err == nil {
+			t.Errorf("parseMarkup(%q) = %+v, want an error", markup, segments)
+		}
+	}
+}
+
+func TestParseBreakTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", defaultBreak},
+		{"250ms", 250},
+		{"1.5s", 1500},
+		{" 2s ", 2000},
+		{"0.5ms", 1},
+		{"0s", 1},
+	}
+	for _, test := range tests {
+		if got, err := parseBreakTime(test.value); err != nil || got != test.want {
+			t.Errorf("parseBreakTime(%q) = %d, %v, want %d", test.value, got, err, test.want)
+		}
+	}
+	for _, value := range []string{"soon", "-1s", "ms", "1.5 minutes"} {
+		if got, err := parseBreakTime(value); err == nil {
+			t.Errorf("parseBreakTime(%q) = %d, want an error", value, got)
+		}
+	}
+}
+
+func TestStripMarkup(t *testing.T) {
+	tests := []struct {
+		markup string
+		want   string
+	}{
+		{"plain  text", "plain text"},
+		{"<emphasis>Run</emphasis>!", "Run!"},
+		{`Press <say-as interpret-as="characters">F1</say-as> now`, "Press F 1 now"},
+		{`Wait<break time="250ms"/>go`, "Wait, go"},
+		{`Wait.<break/>Go`, "Wait. Go"},
+		{`<lang xml:lang="de"><emphasis>Achtung</emphasis></lang> &lt;3`, "Achtung <3"},
+		// Invalid markup falls back to stripping anything that looks like a tag.
+		{"<emphasis>Run &amp; gun", "Run & gun"},
+		{"Tom & <b>Jerry</b>", "Tom & Jerry"},
+		{"a < b", "a < b"},
+	}
+	for _, test := range tests {
+		if got := StripMarkup(test.markup); got != test.want {
+			t.Errorf("StripMarkup(%q) = %q, want %q", test.markup, got, test.want)
+		}
+	}
+}
+
+func TestRenderSsml(t *testing.T) {
+	tests := []struct {
+		markup string
+		want   string
+	}{
+		{"a &amp; b &lt; c", "a &amp; b &lt; c"},
+		{`Wait<break time="1.5s"/>go`, `Wait<break time="1500ms"/>go`},
+		{`<emphasis><say-as interpret-as="characters">HP</say-as></emphasis>`,
+			`<emphasis><say-as interpret-as="characters">HP</say-as></emphasis>`},
+		{`<lang xml:lang="de">Guten <emphasis>Tag</emphasis></lang>`,
+			`<voice xml:lang="de">Guten </voice><voice xml:lang="de"><emphasis>Tag</emphasis></voice>`},
+		{`<unknown>text</unknown>`, "text"},
+	}
+	for _, test := range tests {
+		segments, err := parseMarkup(test.markup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := renderSsml(segments); got != test.want {
+			t.Errorf("renderSsml(%q) = %q, want %q", test.markup, got, test.want)
+		}
+	}
+}
+
+func TestRenderPlain(t *testing.T) {
+	tests := []struct {
+		segments []markupSegment
+		want     string
+	}{
+		{[]markupSegment{{text: "Ammo "}, {text: "BFG", spell: true}, {text: " cells"}}, "Ammo B F G cells"},
+		{[]markupSegment{{text: "HP", spell: true}, {text: "100"}}, "H P 100"},
+		{[]markupSegment{{text: "Wait "}, {pause: 500}, {text: "go"}}, "Wait, go"},
+		{[]markupSegment{{text: "Wait?"}, {pause: 500}, {text: "go"}}, "Wait? go"},
+		{[]markupSegment{{pause: 500}, {text: "go"}}, "go"},
+		{[]markupSegment{{text: "Ready"}, {pause: 500}, {pause: 250}}, "Ready,"},
+		{[]markupSegment{{text: "  Guten ", language: "de"}, {text: "Tag ", emphasis: true}}, "Guten Tag"},
+	}
+	for _, test := range tests {
+		if got := renderPlain(test.segments); got != test.want {
+			t.Errorf("renderPlain(%+v) = %q, want %q", test.segments, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package tts
+
+import (
+	"sort"
+	"strings"
+	"toby_launcher/config"
+	"unicode"
+)
+
+// Normalizer is a stage of the normalization rewriting the text before it is spoken,
+// e.g. expanding "E2M3" to "episode 2, map 3".
+type Normalizer interface {
+	Normalize(text string) string
+}
+
+type normalizerFactoryFunc func(cfg *config.Normalization) Normalizer
+
+type normalizerFactory struct {
+	name   string
+	create normalizerFactoryFunc
+	order  int
+}
+
+var normalizerFactories []normalizerFactory
+
+// RegisterNormalizer registers a stage of the normalization, which can be turned off in the configuration
+// under the name. Stages with a lower order run first.
+func RegisterNormalizer(name string, f normalizerFactoryFunc, order int) {
+	normalizerFactories = append(normalizerFactories, normalizerFactory{name: name, create: f, order: order})
+	sort.SliceStable(normalizerFactories, func(i, j int) bool {
+		return normalizerFactories[i].order < normalizerFactories[j].order
+	})
+}
+
+// NormalizerNames returns the names of the registered stages in the order they run in.
+func NormalizerNames() []string {
+	names := make([]string, 0, len(normalizerFactories))
+	for _, f := range normalizerFactories {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+func createNormalizers(cfg *config.Normalization) []Normalizer {
+	if cfg == nil {
+		return nil
+	}
+	normalizers := make([]Normalizer, 0, len(normalizerFactories))
+	for _, f := range normalizerFactories {
+		if cfg.IsStageEnabled(f.name) {
+			normalizers = append(normalizers, f.create(cfg))
+		}
+	}
+	return normalizers
+}
+
+// UpdateNormalization creates the stages of the normalization again after its settings have been changed.
+func (m *TtsManager) UpdateNormalization() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.normalizers = createNormalizers(m.config.Normalization)
+}
+
+// normalize runs the text through the enabled stages. It is called with the mutex locked.
+func (m *TtsManager) normalize(text string) string {
+	if len(m.normalizers) == 0 {
+		return text
+	}
+	for _, n := range m.normalizers {
+		text = n.Normalize(text)
+	}
+	normalized := strings.Join(strings.Fields(text), " ")
+	// The spaces at the edges separate the text from the neighbouring segments of markup.
+	if normalized != "" && strings.TrimLeftFunc(text, unicode.IsSpace) != text {
+		normalized = " " + normalized
+	}
+	if normalized != "" && strings.TrimRightFunc(text, unicode.IsSpace) != text {
+		normalized += " "
+	}
+	return normalized
+}
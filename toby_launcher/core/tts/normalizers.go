@@ -0,0 +1,196 @@
+package tts
+
+import (
+	"regexp"
+	"strings"
+	"toby_launcher/config"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterNormalizer("repeated_symbols", func(*config.Normalization) Normalizer { return NewRepeatedSymbolNormalizer() }, 10)
+	RegisterNormalizer("map_codes", func(*config.Normalization) Normalizer { return NewMapCodeNormalizer() }, 20)
+	RegisterNormalizer("numbers", func(*config.Normalization) Normalizer { return NewNumberNormalizer() }, 30)
+	RegisterNormalizer("abbreviations", func(cfg *config.Normalization) Normalizer {
+		return NewAbbreviationNormalizer(cfg.Abbreviations)
+	}, 40)
+	RegisterNormalizer("punctuation", func(cfg *config.Normalization) Normalizer {
+		return NewPunctuationNormalizer(config.PunctuationLevel(cfg.Punctuation))
+	}, 50)
+}
+
+// RepeatedSymbolNormalizer collapses a run of the same symbol, such as "!!!" or "-----", to one symbol.
+type RepeatedSymbolNormalizer struct{}
+
+func NewRepeatedSymbolNormalizer() *RepeatedSymbolNormalizer {
+	return &RepeatedSymbolNormalizer{}
+}
+
+func (n *RepeatedSymbolNormalizer) Normalize(text string) string {
+	var result strings.Builder
+	previous := rune(-1)
+	for _, r := range text {
+		isSymbol := !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+		if isSymbol && r == previous {
+			continue
+		}
+		result.WriteRune(r)
+		previous = r
+	}
+	return result.String()
+}
+
+// MapCodeNormalizer expands the map codes of Doom, "E2M3" to "episode 2, map 3" and "MAP07" to "map 7".
+type MapCodeNormalizer struct {
+	episodeMap *regexp.Regexp
+	mapNumber  *regexp.Regexp
+}
+
+func NewMapCodeNormalizer() *MapCodeNormalizer {
+	return &MapCodeNormalizer{
+		episodeMap: regexp.MustCompile(`(?i)\bE0*(\d+)M0*(\d+)\b`),
+		mapNumber:  regexp.MustCompile(`(?i)\bMAP0*(\d+)\b`),
+	}
+}
+
+func (n *MapCodeNormalizer) Normalize(text string) string {
+	text = n.episodeMap.ReplaceAllString(text, "episode $1, map $2")
+	return n.mapNumber.ReplaceAllString(text, "map $1")
+}
+
+// NumberNormalizer expands percents and signs of numbers, "+25" to "plus 25" and "100%" to "100 percent",
+// and removes the thousands separators and the leading zeros of standalone numbers, which engines read digit by digit.
+// Times such as "01:05" and decimals keep their zeros.
+type NumberNormalizer struct {
+	percent   *regexp.Regexp
+	sign      *regexp.Regexp
+	thousands *regexp.Regexp
+	zeros     *regexp.Regexp
+}
+
+func NewNumberNormalizer() *NumberNormalizer {
+	return &NumberNormalizer{
+		percent:   regexp.MustCompile(`(\d)\s?%`),
+		sign:      regexp.MustCompile(`(^|[\s(\[:])([+-])(\d)`),
+		thousands: regexp.MustCompile(`\b\d{1,3}(,\d{3})+\b`),
+		zeros:     regexp.MustCompile(`(^[+-]?|[\s(\[][+-]?)0+(\d+)\b([:.,]\d)?`),
+	}
+}
+
+func (n *NumberNormalizer) Normalize(text string) string {
+	text = n.thousands.ReplaceAllStringFunc(text, func(number string) string {
+		return strings.ReplaceAll(number, ",", "")
+	})
+	text = n.zeros.ReplaceAllStringFunc(text, func(match string) string {
+		groups := n.zeros.FindStringSubmatch(match)
+		if groups[3] != "" {
+			return match
+		}
+		return groups[1] + groups[2]
+	})
+	text = n.percent.ReplaceAllString(text, "$1 percent")
+	return n.sign.ReplaceAllStringFunc(text, func(match string) string {
+		groups := n.sign.FindStringSubmatch(match)
+		word := "plus "
+		if groups[2] == "-" {
+			word = "minus "
+		}
+		return groups[1] + word + groups[3]
+	})
+}
+
+// AbbreviationNormalizer replaces the abbreviations with their spoken forms and lowers the case
+// of the other words written in capitals, which some engines spell out. Words of up to three letters
+// are kept in capitals as abbreviations, unless the whole text is shouted in capitals.
+type AbbreviationNormalizer struct {
+	abbreviations map[string]string
+	word          *regexp.Regexp
+}
+
+func NewAbbreviationNormalizer(abbreviations map[string]string) *AbbreviationNormalizer {
+	return &AbbreviationNormalizer{
+		abbreviations: abbreviations,
+		word:          regexp.MustCompile(`\p{L}[\p{L}\p{N}]*`),
+	}
+}
+
+func (n *AbbreviationNormalizer) Normalize(text string) string {
+	// The text is shouted if it has several words and all of them are in capitals.
+	words := 0
+	isShouting := true
+	for _, word := range n.word.FindAllString(text, -1) {
+		if utf8.RuneCountInString(word) > 1 {
+			words++
+			isShouting = isShouting && isCapitalized(word)
+		}
+	}
+	isShouting = isShouting && words > 1
+	return n.word.ReplaceAllStringFunc(text, func(word string) string {
+		if spoken, exists := n.abbreviations[word]; exists {
+			return spoken
+		}
+		if isCapitalized(word) && (isShouting || utf8.RuneCountInString(word) > 3) {
+			return strings.ToLower(word)
+		}
+		return word
+	})
+}
+
+// isCapitalized reports whether all letters of the word are capitals.
+func isCapitalized(word string) bool {
+	return strings.ToUpper(word) == word && strings.ToLower(word) != word
+}
+
+type symbolName struct {
+	name  string
+	level int
+}
+
+// symbolNames are the names of the symbols and the punctuation levels they are spoken at.
+var symbolNames = map[rune]symbolName{
+	'&': {"and", 1}, '@': {"at", 1}, '#': {"number", 1}, '*': {"star", 1}, '+': {"plus", 1},
+	'=': {"equals", 1}, '/': {"slash", 1}, '\\': {"backslash", 1}, '|': {"bar", 1}, '~': {"tilde", 1},
+	'^': {"caret", 1}, '_': {"underscore", 1}, '<': {"less", 1}, '>': {"greater", 1}, '$': {"dollar", 1},
+	'%': {"percent", 1},
+	'(': {"left paren", 2}, ')': {"right paren", 2}, '[': {"left bracket", 2}, ']': {"right bracket", 2},
+	'{': {"left brace", 2}, '}': {"right brace", 2}, '"': {"quote", 2}, ':': {"colon", 2}, ';': {"semicolon", 2},
+	'-': {"dash", 2},
+	'.': {"dot", 3}, ',': {"comma", 3}, '!': {"bang", 3}, '?': {"question", 3}, '\'': {"apostrophe", 3},
+}
+
+// PunctuationNormalizer speaks the symbols by name at the level, the index of the level in config.PunctuationLevels.
+// Hyphens, apostrophes, dots and commas inside words and numbers are not spoken.
+type PunctuationNormalizer struct {
+	level int
+}
+
+func NewPunctuationNormalizer(level int) *PunctuationNormalizer {
+	return &PunctuationNormalizer{level: level}
+}
+
+func (n *PunctuationNormalizer) Normalize(text string) string {
+	if n.level <= 0 {
+		return text
+	}
+	var result strings.Builder
+	for i, r := range text {
+		symbol, exists := symbolNames[r]
+		if !exists || symbol.level > n.level || isInsideWord(text, i, r) {
+			result.WriteRune(r)
+			continue
+		}
+		result.WriteString(" " + symbol.name + " ")
+	}
+	return result.String()
+}
+
+func isInsideWord(text string, i int, r rune) bool {
+	if r != '-' && r != '\'' && r != '.' && r != ',' {
+		return false
+	}
+	before, _ := utf8.DecodeLastRuneInString(text[:i])
+	after, _ := utf8.DecodeRuneInString(text[i+utf8.RuneLen(r):])
+	isWordRune := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	return isWordRune(before) && isWordRune(after)
+}
@@ -0,0 +1,101 @@
+package tts
+
+import (
+	"testing"
+	"toby_launcher/config"
+)
+
+type normalizerTest struct {
+	text string
+	want string
+}
+
+func testNormalizer(t *testing.T, n Normalizer, tests []normalizerTest) {
+	t.Helper()
+	for _, test := range tests {
+		if got := n.Normalize(test.text); got != test.want {
+			t.Errorf("Normalize(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestRepeatedSymbolNormalizer(t *testing.T) {
+	testNormalizer(t, NewRepeatedSymbolNormalizer(), []normalizerTest{
+		{"Wait!!!", "Wait!"},
+		{"-----", "-"},
+		{"?!?!", "?!?!"},
+		{"Doom  II", "Doom  II"},
+		{"1000", "1000"},
+	})
+}
+
+func TestMapCodeNormalizer(t *testing.T) {
+	testNormalizer(t, NewMapCodeNormalizer(), []normalizerTest{
+		{"E2M3", "episode 2, map 3"},
+		{"e1m08: Phobos Lab", "episode 1, map 8: Phobos Lab"},
+		{"MAP07", "map 7"},
+		{"Entering map10.", "Entering map 10."},
+		{"MAP00", "map 0"},
+		{"XE1M1 and MAP07X", "XE1M1 and MAP07X"},
+	})
+}
+
+func TestNumberNormalizer(t *testing.T) {
+	testNormalizer(t, NewNumberNormalizer(), []normalizerTest{
+		{"+25", "plus 25"},
+		{"Health: +25", "Health: plus 25"},
+		{"(-5)", "(minus 5)"},
+		{"1-2", "1-2"},
+		{"100%", "100 percent"},
+		{"50 %", "50 percent"},
+		{"1,000", "1000"},
+		{"12,345,678 kills", "12345678 kills"},
+		{"1,00", "1,00"},
+		{"007", "7"},
+		{"Frags: 0042", "Frags: 42"},
+		{"0", "0"},
+		{"100", "100"},
+		{"3.05", "3.05"},
+		{"-007%", "minus 7 percent"},
+		{"007 008", "7 8"},
+		{"(05)", "(5)"},
+		{"007s", "007s"},
+		{"10:05", "10:05"},
+		{"Time: 01:05", "Time: 01:05"},
+		{"Time: 00:01:05", "Time: 00:01:05"},
+		{"00.5", "00.5"},
+		{"E1M1 12:00", "E1M1 12:00"},
+	})
+}
+
+func TestAbbreviationNormalizer(t *testing.T) {
+	abbreviations := map[string]string{"BFG": "big fragging gun"}
+	testNormalizer(t, NewAbbreviationNormalizer(abbreviations), []normalizerTest{
+		{"BFG picked up", "big fragging gun picked up"},
+		{"HUD is on", "HUD is on"},
+		{"LOADING the level", "loading the level"},
+		{"OK", "OK"},
+		{"OK then", "OK then"},
+		// The text is shouted when all its words are in capitals.
+		{"YOU ARE DEAD", "you are dead"},
+		{"GET READY!", "get ready!"},
+		{"YOU got THE BFG", "YOU got THE big fragging gun"},
+		{"Ünter ÜBER", "Ünter über"},
+	})
+}
+
+func TestPunctuationNormalizer(t *testing.T) {
+	testNormalizer(t, NewPunctuationNormalizer(config.PunctuationLevel("none")), []normalizerTest{
+		{"a & b.", "a & b."},
+	})
+	testNormalizer(t, NewPunctuationNormalizer(config.PunctuationLevel("some")), []normalizerTest{
+		{"a & b (c).", "a  and  b (c)."},
+	})
+	testNormalizer(t, NewPunctuationNormalizer(config.PunctuationLevel("most")), []normalizerTest{
+		{"(c): d", " left paren c right paren  colon  d"},
+		{"self-voicing", "self-voicing"},
+	})
+	testNormalizer(t, NewPunctuationNormalizer(config.PunctuationLevel("all")), []normalizerTest{
+		{"Hi, it's 3.5!", "Hi comma  it's 3.5 bang "},
+	})
+}
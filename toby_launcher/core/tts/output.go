@@ -0,0 +1,131 @@
+package tts
+
+import (
+	"toby_launcher/apperrors"
+)
+
+// Output receives the text of every spoken phrase in addition to the speech synthesizer,
+// such as a braille display or a text log.
+type Output interface {
+	Name() string
+	Write(text string) error
+	Release()
+}
+
+type outputFactoryFunc func() (Output, error)
+
+type outputFactory struct {
+	name   string
+	create outputFactoryFunc
+}
+
+var outputFactories []outputFactory
+
+// RegisterOutput registers an output which can be enabled in the configuration under the name.
+func RegisterOutput(name string, f outputFactoryFunc) {
+	outputFactories = append(outputFactories, outputFactory{name: name, create: f})
+}
+
+// OutputNames returns the names of the registered outputs in the order of registration.
+func OutputNames() []string {
+	names := make([]string, 0, len(outputFactories))
+	for _, f := range outputFactories {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+func createOutput(name string) (Output, error) {
+	for _, f := range outputFactories {
+		if f.name == name {
+			output, err := f.create()
+			if err != nil {
+				return nil, apperrors.New(apperrors.ErrSpeech, "Failed to open the output \"$output\": $error", map[string]any{"output": name, "error": err})
+			}
+			return output, nil
+		}
+	}
+	return nil, apperrors.New(apperrors.ErrSpeech, "The output \"$output\" is missing.", map[string]any{"output": name})
+}
+
+// openOutputs opens the outputs enabled in the configuration. An output which fails to open
+// stays enabled, so it is opened the next time.
+func (m *TtsManager) openOutputs() {
+	for _, name := range m.config.Outputs {
+		output, err := createOutput(name)
+		if err != nil {
+			m.logger.Error(err)
+			continue
+		}
+		m.outputs = append(m.outputs, output)
+	}
+}
+
+// writeOutputs writes the text to the open outputs.
+func (m *TtsManager) writeOutputs(text string) {
+	m.outputsMu.Lock()
+	defer m.outputsMu.Unlock()
+	for _, output := range m.outputs {
+		if err := output.Write(text); err != nil {
+			m.logger.DebugError(apperrors.New(apperrors.ErrSpeech, "Failed to write to the output \"$output\": $error", map[string]any{"output": output.Name(), "error": err}))
+		}
+	}
+}
+
+func (m *TtsManager) releaseOutputs() {
+	m.outputsMu.Lock()
+	defer m.outputsMu.Unlock()
+	for _, output := range m.outputs {
+		output.Release()
+	}
+	m.outputs = nil
+}
+
+// IsOutputEnabled reports whether the output is enabled in the configuration.
+func (m *TtsManager) IsOutputEnabled(name string) bool {
+	m.outputsMu.Lock()
+	defer m.outputsMu.Unlock()
+	for _, n := range m.config.Outputs {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// EnableOutput opens or releases the output and saves its state in the configuration.
+// The new output is opened before the old one is released, so the outputs are left
+// as they were if it fails to open.
+func (m *TtsManager) EnableOutput(name string, enabled bool) error {
+	m.outputsMu.Lock()
+	defer m.outputsMu.Unlock()
+	var created Output
+	if enabled {
+		output, err := createOutput(name)
+		if err != nil {
+			return err
+		}
+		created = output
+	}
+	names := make([]string, 0, len(m.config.Outputs)+1)
+	for _, n := range m.config.Outputs {
+		if n != name {
+			names = append(names, n)
+		}
+	}
+	outputs := make([]Output, 0, len(m.outputs)+1)
+	for _, output := range m.outputs {
+		if output.Name() == name {
+			output.Release()
+		} else {
+			outputs = append(outputs, output)
+		}
+	}
+	if created != nil {
+		outputs = append(outputs, created)
+		names = append(names, name)
+	}
+	m.outputs = outputs
+	m.config.Outputs = names
+	return nil
+}
@@ -0,0 +1,510 @@
+package tts
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"toby_launcher/apperrors"
+)
+
+// Priority defines which phrases are spoken first. Phrases of a higher priority are spoken before
+// queued phrases of a lower priority and are not interrupted by them.
+type Priority int
+
+const (
+	ChatterPriority Priority = iota
+	NormalPriority
+	CriticalPriority
+	UiPriority
+)
+
+var priorityNames = map[Priority]string{
+	ChatterPriority:  "chatter",
+	NormalPriority:   "normal",
+	CriticalPriority: "critical",
+	UiPriority:       "ui",
+}
+
+func (p Priority) String() string {
+	if name, exists := priorityNames[p]; exists {
+		return name
+	}
+	return "unknown"
+}
+
+func ParsePriority(name string) (Priority, error) {
+	for priority, priorityName := range priorityNames {
+		if strings.EqualFold(name, priorityName) {
+			return priority, nil
+		}
+	}
+	return NormalPriority, apperrors.New(apperrors.Err, "unknown speech priority \"$priority\"", map[string]any{"priority": name})
+}
+
+// Policy defines what happens to a phrase when another phrase is being spoken.
+type Policy int
+
+const (
+	// DefaultPolicy uses the policy of the priority of the phrase.
+	DefaultPolicy Policy = iota
+	// InterruptPolicy stops the current phrase unless it has a higher priority,
+	// and drops queued phrases that have the same or a lower priority.
+	InterruptPolicy
+	// EnqueuePolicy waits until the queued phrases of the same or a higher priority are spoken.
+	EnqueuePolicy
+	// DropIfBusyPolicy drops the phrase if anything is being spoken or waiting to be spoken.
+	DropIfBusyPolicy
+)
+
+func (p *Phrase) policy() Policy {
+	if p.Policy != DefaultPolicy {
+		return p.Policy
+	}
+	switch p.Priority {
+	case UiPriority:
+		return InterruptPolicy
+	case ChatterPriority:
+		return DropIfBusyPolicy
+	default:
+		return EnqueuePolicy
+	}
+}
+
+// PhraseStatus tells how the speech of a phrase ended.
+type PhraseStatus int
+
+const (
+	// PhraseSpoken phrases were spoken to the end.
+	PhraseSpoken PhraseStatus = iota
+	// PhraseInterrupted phrases were stopped or removed from the queue by another phrase or by flushing.
+	PhraseInterrupted
+	// PhraseDropped phrases were not spoken because the queue was busy or too long.
+	PhraseDropped
+	// PhraseFailed phrases could not be spoken by the synthesizer.
+	PhraseFailed
+)
+
+var phraseStatusNames = map[PhraseStatus]string{
+	PhraseSpoken:      "spoken",
+	PhraseInterrupted: "interrupted",
+	PhraseDropped:     "dropped",
+	PhraseFailed:      "failed",
+}
+
+func (s PhraseStatus) String() string {
+	if name, exists := phraseStatusNames[s]; exists {
+		return name
+	}
+	return "unknown"
+}
+
+const (
+	queuePollInterval = 50 * time.Millisecond
+	// completionCheckInterval is how often the queue checks a synthesizer that reports the end
+	// of its phrases, in case a report is lost.
+	completionCheckInterval = time.Second
+)
+
+// speaker is the synthesizer the queue speaks through.
+type speaker interface {
+	// prepare returns the parts of the phrase, which are spoken by speakNow one after another.
+	prepare(phrase *Phrase) []*Phrase
+	speakNow(phrase *Phrase) error
+	stopNow()
+	isSpeakingNow() bool
+	// reportsCompletion reports whether the end of the phrases is reported through speechQueue.complete.
+	reportsCompletion() bool
+	logError(err error)
+}
+
+// speechQueue speaks phrases one after another in a separate goroutine.
+// Every phrase pushed to the queue finishes exactly once with a PhraseStatus.
+type speechQueue struct {
+	mu        sync.Mutex
+	speaker   speaker
+	phrases   []*Phrase
+	current   *Phrase
+	maxLength int
+	// pending holds a channel for every queued or current phrase, closed when the phrase finishes.
+	pending map[int]chan struct{}
+	// idle is closed while nothing is being spoken or waiting to be spoken.
+	idle      chan struct{}
+	onIdle    []func()
+	completed chan int
+	// stopId is the id of the current phrase that has to be stopped. The phrase is stopped by the goroutine
+	// of the queue, so the stop can't hit the phrase spoken after it.
+	stopId    int
+	wake      chan struct{}
+	interrupt chan struct{}
+	done      chan struct{}
+	wg        sync.WaitGroup
+	once      sync.Once
+}
+
+func newSpeechQueue(s speaker, maxLength int) *speechQueue {
+	q := &speechQueue{
+		speaker:   s,
+		phrases:   make([]*Phrase, 0, maxLength),
+		maxLength: maxLength,
+		pending:   make(map[int]chan struct{}),
+		idle:      make(chan struct{}),
+		completed: make(chan int, 8),
+		wake:      make(chan struct{}, 1),
+		interrupt: make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	close(q.idle)
+	q.wg.Add(1)
+	go q.run()
+	return q
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// finishedPhrase is a phrase that has finished with the status, its callback is called
+// after the mutex is unlocked, so the callback can speak another phrase.
+type finishedPhrase struct {
+	phrase *Phrase
+	status PhraseStatus
+}
+
+// push adds the phrase to the queue according to its policy and reports whether it was accepted.
+func (q *speechQueue) push(phrase *Phrase) bool {
+	q.mu.Lock()
+	interrupt := false
+	finished := make([]finishedPhrase, 0, 1)
+	switch phrase.policy() {
+	case DropIfBusyPolicy:
+		if q.current != nil || len(q.phrases) > 0 {
+			q.mu.Unlock()
+			q.notify([]finishedPhrase{{phrase, PhraseDropped}}, false)
+			return false
+		}
+	case InterruptPolicy:
+		if q.current == nil || q.current.Priority <= phrase.Priority {
+			kept := q.phrases[:0]
+			for _, p := range q.phrases {
+				if p.Priority > phrase.Priority {
+					kept = append(kept, p)
+				} else {
+					finished = append(finished, q.finish(p, PhraseInterrupted))
+				}
+			}
+			q.phrases = kept
+			if q.current != nil {
+				q.stopId = q.current.Id
+				interrupt = true
+			}
+		}
+	}
+	q.pending[phrase.Id] = make(chan struct{})
+	q.insert(phrase)
+	finished = append(finished, q.trim()...)
+	accepted := true
+	for _, f := range finished {
+		if f.phrase == phrase {
+			accepted = false
+		}
+	}
+	if len(q.phrases) > 0 {
+		q.setBusy()
+	}
+	q.mu.Unlock()
+	q.notify(finished, false)
+	if interrupt {
+		signal(q.interrupt)
+	}
+	signal(q.wake)
+	return accepted
+}
+
+// insert places the phrase after the queued phrases of the same or a higher priority.
+func (q *speechQueue) insert(phrase *Phrase) {
+	pos := len(q.phrases)
+	for i, p := range q.phrases {
+		if p.Priority < phrase.Priority {
+			pos = i
+			break
+		}
+	}
+	q.phrases = append(q.phrases, nil)
+	copy(q.phrases[pos+1:], q.phrases[pos:])
+	q.phrases[pos] = phrase
+}
+
+// trim drops the oldest phrases of the lowest priority while the queue is too long.
+func (q *speechQueue) trim() []finishedPhrase {
+	finished := make([]finishedPhrase, 0)
+	for q.maxLength > 0 && len(q.phrases) > q.maxLength {
+		lowest := 0
+		for i, p := range q.phrases {
+			if p.Priority < q.phrases[lowest].Priority {
+				lowest = i
+			}
+		}
+		finished = append(finished, q.finish(q.phrases[lowest], PhraseDropped))
+		q.phrases = append(q.phrases[:lowest], q.phrases[lowest+1:]...)
+	}
+	return finished
+}
+
+// finish marks the phrase as finished, waking up the callers of waitFor. It is called with the mutex locked.
+func (q *speechQueue) finish(phrase *Phrase, status PhraseStatus) finishedPhrase {
+	if ch, exists := q.pending[phrase.Id]; exists {
+		close(ch)
+		delete(q.pending, phrase.Id)
+	}
+	return finishedPhrase{phrase, status}
+}
+
+func (q *speechQueue) setBusy() {
+	select {
+	case <-q.idle:
+		q.idle = make(chan struct{})
+	default:
+	}
+}
+
+// setIdle marks the queue as idle if nothing is queued and reports whether it has just become idle.
+// It is called with the mutex locked.
+func (q *speechQueue) setIdle() bool {
+	if q.current != nil || len(q.phrases) > 0 {
+		return false
+	}
+	select {
+	case <-q.idle:
+		return false
+	default:
+		close(q.idle)
+		return true
+	}
+}
+
+// notify calls the callbacks of the finished phrases and, if the queue has become idle, the idle callbacks.
+func (q *speechQueue) notify(finished []finishedPhrase, becameIdle bool) {
+	for _, f := range finished {
+		if f.phrase.OnDone != nil {
+			f.phrase.OnDone(f.status)
+		}
+	}
+	if !becameIdle {
+		return
+	}
+	q.mu.Lock()
+	callbacks := q.onIdle
+	q.mu.Unlock()
+	for _, f := range callbacks {
+		f()
+	}
+}
+
+// flush drops the queued phrases and stops the current one.
+func (q *speechQueue) flush() {
+	q.mu.Lock()
+	finished := make([]finishedPhrase, 0, len(q.phrases))
+	for _, p := range q.phrases {
+		finished = append(finished, q.finish(p, PhraseInterrupted))
+	}
+	q.phrases = q.phrases[:0]
+	if q.current != nil {
+		q.stopId = q.current.Id
+	}
+	becameIdle := q.setIdle()
+	q.mu.Unlock()
+	q.notify(finished, becameIdle)
+	signal(q.interrupt)
+}
+
+// isStopped reports whether the phrase has to be stopped.
+func (q *speechQueue) isStopped(phrase *Phrase) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.stopId == phrase.Id
+}
+
+// waitIdle waits until nothing is being spoken or waiting to be spoken and reports whether
+// the queue became idle before the timeout.
+func (q *speechQueue) waitIdle(timeout time.Duration) bool {
+	q.mu.Lock()
+	idle := q.idle
+	q.mu.Unlock()
+	select {
+	case <-idle:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// waitFor waits until the phrase with the id finishes and reports whether it finished before the timeout.
+// A phrase that is not queued has already finished.
+func (q *speechQueue) waitFor(id int, timeout time.Duration) bool {
+	q.mu.Lock()
+	ch, exists := q.pending[id]
+	q.mu.Unlock()
+	if !exists {
+		return true
+	}
+	select {
+	case <-ch:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (q *speechQueue) addIdleCallback(f func()) {
+	q.mu.Lock()
+	q.onIdle = append(q.onIdle, f)
+	q.mu.Unlock()
+}
+
+// complete is called when the synthesizer reports the end of the phrase with the id.
+// It does not block, as synthesizers call it from their own goroutines.
+func (q *speechQueue) complete(id int) {
+	select {
+	case q.completed <- id:
+	default:
+	}
+}
+
+func (q *speechQueue) setMaxLength(maxLength int) {
+	q.mu.Lock()
+	q.maxLength = maxLength
+	finished := q.trim()
+	q.mu.Unlock()
+	q.notify(finished, false)
+}
+
+// stop stops the goroutine of the queue, the phrases left in the queue finish as interrupted.
+func (q *speechQueue) stop() {
+	q.once.Do(func() {
+		close(q.done)
+		q.wg.Wait()
+		q.mu.Lock()
+		finished := make([]finishedPhrase, 0, len(q.phrases))
+		for _, p := range q.phrases {
+			finished = append(finished, q.finish(p, PhraseInterrupted))
+		}
+		q.phrases = q.phrases[:0]
+		becameIdle := q.setIdle()
+		q.mu.Unlock()
+		q.notify(finished, becameIdle)
+	})
+}
+
+func (q *speechQueue) run() {
+	defer q.wg.Done()
+	for {
+		phrase := q.next()
+		if phrase == nil {
+			return
+		}
+		status := q.play(phrase)
+		q.mu.Lock()
+		q.current = nil
+		finished := []finishedPhrase{q.finish(phrase, status)}
+		becameIdle := q.setIdle()
+		q.mu.Unlock()
+		q.notify(finished, becameIdle)
+	}
+}
+
+// next waits for a phrase and makes it current. It returns nil when the queue is stopped.
+func (q *speechQueue) next() *Phrase {
+	for {
+		q.mu.Lock()
+		if len(q.phrases) > 0 {
+			phrase := q.phrases[0]
+			q.phrases = q.phrases[1:]
+			q.current = phrase
+			q.mu.Unlock()
+			return phrase
+		}
+		q.mu.Unlock()
+		select {
+		case <-q.done:
+			return nil
+		case <-q.wake:
+		}
+	}
+}
+
+// play speaks the parts of the phrase and waits until they are finished or interrupted.
+func (q *speechQueue) play(phrase *Phrase) PhraseStatus {
+	parts := q.speaker.prepare(phrase)
+	if phrase.Silence > 0 {
+		silence := time.NewTimer(time.Duration(phrase.Silence) * time.Millisecond)
+		defer silence.Stop()
+	wait:
+		for {
+			select {
+			case <-q.done:
+				return PhraseInterrupted
+			case <-q.interrupt:
+				// The interrupts of the phrases spoken before are ignored.
+				if q.isStopped(phrase) {
+					return PhraseInterrupted
+				}
+			case <-silence.C:
+				break wait
+			}
+		}
+	}
+	for _, part := range parts {
+		if q.isStopped(phrase) {
+			return PhraseInterrupted
+		}
+		partCopy := *part
+		partCopy.Silence = 0
+		if status := q.playPart(&partCopy); status != PhraseSpoken {
+			return status
+		}
+	}
+	return PhraseSpoken
+}
+
+// playPart speaks a part of a phrase and waits until it is finished or interrupted. The end of the part
+// is reported by the synthesizer if it can, otherwise the synthesizer is polled until it stops speaking.
+func (q *speechQueue) playPart(phrase *Phrase) PhraseStatus {
+	// The parts of a phrase share its id, so a late report of the previous part is dropped.
+	select {
+	case <-q.completed:
+	default:
+	}
+	if err := q.speaker.speakNow(phrase); err != nil {
+		q.speaker.logError(err)
+		return PhraseFailed
+	}
+	interval := queuePollInterval
+	if q.speaker.reportsCompletion() {
+		interval = completionCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.done:
+			return PhraseInterrupted
+		case <-q.interrupt:
+			if q.isStopped(phrase) {
+				q.speaker.stopNow()
+				return PhraseInterrupted
+			}
+		case id := <-q.completed:
+			// Reports of the phrases stopped before are ignored.
+			if id == phrase.Id {
+				return PhraseSpoken
+			}
+		case <-ticker.C:
+			if !q.speaker.isSpeakingNow() {
+				return PhraseSpoken
+			}
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package tts
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSpeaker speaks every phrase until it is stopped or released by the test.
+type fakeSpeaker struct {
+	mu       sync.Mutex
+	speaking bool
+	spoken   []int
+	stops    int
+	started  chan int
+}
+
+func newFakeSpeaker() *fakeSpeaker {
+	return &fakeSpeaker{started: make(chan int, 10)}
+}
+
+func (s *fakeSpeaker) prepare(phrase *Phrase) []*Phrase {
+	return []*Phrase{phrase}
+}
+
+func (s *fakeSpeaker) speakNow(phrase *Phrase) error {
+	s.mu.Lock()
+	s.speaking = true
+	s.spoken = append(s.spoken, phrase.Id)
+	s.mu.Unlock()
+	s.started <- phrase.Id
+	return nil
+}
+
+func (s *fakeSpeaker) stopNow() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.speaking = false
+	s.stops++
+}
+
+func (s *fakeSpeaker) isSpeakingNow() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.speaking
+}
+
+func (s *fakeSpeaker) reportsCompletion() bool { return false }
+
+func (s *fakeSpeaker) logError(err error) {}
+
+// finishSpeaking ends the current phrase as if it was spoken to the end.
+func (s *fakeSpeaker) finishSpeaking() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.speaking = false
+}
+
+func waitStarted(t *testing.T, s *fakeSpeaker, id int) {
+	t.Helper()
+	select {
+	case started := <-s.started:
+		if started != id {
+			t.Fatalf("phrase %d started, want %d", started, id)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("phrase %d has not started", id)
+	}
+}
+
+// waitStatus waits until a phrase finishes and returns its status.
+func waitStatus(t *testing.T, statuses <-chan PhraseStatus) PhraseStatus {
+	t.Helper()
+	select {
+	case status := <-statuses:
+		return status
+	case <-time.After(time.Second):
+		t.Fatal("the phrase has not finished")
+		return 0
+	}
+}
+
+func queuedIds(q *speechQueue) []int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ids := make([]int, 0, len(q.phrases))
+	for _, p := range q.phrases {
+		ids = append(ids, p.Id)
+	}
+	return ids
+}
+
+func newTestPhrase(id int, priority Priority, statuses chan<- PhraseStatus) *Phrase {
+	return &Phrase{
+		Id:       id,
+		Text:     "text",
+		Priority: priority,
+		OnDone:   func(status PhraseStatus) { statuses <- status },
+	}
+}
+
+func TestInterruptStopsCurrentPhrase(t *testing.T) {
+	s := newFakeSpeaker()
+	q := newSpeechQueue(s, 10)
+	defer q.stop()
+	first := make(chan PhraseStatus, 1)
+	second := make(chan PhraseStatus, 1)
+	q.push(newTestPhrase(1, NormalPriority, first))
+	waitStarted(t, s, 1)
+	q.push(newTestPhrase(2, UiPriority, second))
+	if status := waitStatus(t, first); status != PhraseInterrupted {
+		t.Errorf("interrupted phrase finished as %s", status)
+	}
+	waitStarted(t, s, 2)
+	s.finishSpeaking()
+	if status := waitStatus(t, second); status != PhraseSpoken {
+		t.Errorf("interrupting phrase finished as %s", status)
+	}
+}
+
+func TestLateInterruptDoesNotStopNextPhrase(t *testing.T) {
+	s := newFakeSpeaker()
+	q := newSpeechQueue(s, 10)
+	defer q.stop()
+	statuses := make(chan PhraseStatus, 2)
+	q.push(newTestPhrase(1, NormalPriority, statuses))
+	waitStarted(t, s, 1)
+	q.push(newTestPhrase(2, NormalPriority, statuses))
+	// The interrupt aimed at the first phrase arrives after it has been spoken.
+	s.finishSpeaking()
+	if status := waitStatus(t, statuses); status != PhraseSpoken {
+		t.Fatalf("first phrase finished as %s", status)
+	}
+	waitStarted(t, s, 2)
+	q.mu.Lock()
+	q.stopId = 1
+	q.mu.Unlock()
+	signal(q.interrupt)
+	time.Sleep(2 * queuePollInterval)
+	s.finishSpeaking()
+	if status := waitStatus(t, statuses); status != PhraseSpoken {
+		t.Errorf("second phrase finished as %s", status)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stops != 0 {
+		t.Errorf("the synthesizer was stopped %d times", s.stops)
+	}
+}
+
+func TestFlushInterruptsQueuedPhrases(t *testing.T) {
+	s := newFakeSpeaker()
+	q := newSpeechQueue(s, 10)
+	defer q.stop()
+	statuses := make(chan PhraseStatus, 3)
+	for id := 1; id <= 3; id++ {
+		q.push(newTestPhrase(id, NormalPriority, statuses))
+	}
+	waitStarted(t, s, 1)
+	q.flush()
+	for i := 0; i < 3; i++ {
+		if status := waitStatus(t, statuses); status != PhraseInterrupted {
+			t.Errorf("flushed phrase finished as %s", status)
+		}
+	}
+	if !q.waitIdle(time.Second) {
+		t.Error("the queue is not idle after flushing")
+	}
+}
+
+func TestDropIfBusyDropsWhileSpeaking(t *testing.T) {
+	s := newFakeSpeaker()
+	q := newSpeechQueue(s, 10)
+	defer q.stop()
+	first := make(chan PhraseStatus, 1)
+	chatter := make(chan PhraseStatus, 1)
+	normal := make(chan PhraseStatus, 1)
+	// Chatter is spoken when nothing else is.
+	if !q.push(newTestPhrase(1, ChatterPriority, first)) {
+		t.Fatal("chatter was dropped by the idle queue")
+	}
+	waitStarted(t, s, 1)
+	if q.push(newTestPhrase(2, ChatterPriority, chatter)) {
+		t.Error("chatter was accepted while speaking")
+	}
+	if status := waitStatus(t, chatter); status != PhraseDropped {
+		t.Errorf("chatter finished as %s", status)
+	}
+	// The policy of the phrase overrides the policy of its priority.
+	phrase := newTestPhrase(3, NormalPriority, normal)
+	phrase.Policy = DropIfBusyPolicy
+	if q.push(phrase) {
+		t.Error("a phrase dropped if busy was accepted while speaking")
+	}
+	if status := waitStatus(t, normal); status != PhraseDropped {
+		t.Errorf("a phrase dropped if busy finished as %s", status)
+	}
+	s.finishSpeaking()
+	if status := waitStatus(t, first); status != PhraseSpoken {
+		t.Errorf("first phrase finished as %s", status)
+	}
+}
+
+func TestInsertOrdersByPriority(t *testing.T) {
+	s := newFakeSpeaker()
+	q := newSpeechQueue(s, 10)
+	defer q.stop()
+	statuses := make(chan PhraseStatus, 6)
+	q.push(newTestPhrase(1, NormalPriority, statuses))
+	waitStarted(t, s, 1)
+	priorities := []Priority{NormalPriority, ChatterPriority, CriticalPriority, NormalPriority, CriticalPriority}
+	for i, priority := range priorities {
+		phrase := newTestPhrase(i+2, priority, statuses)
+		phrase.Policy = EnqueuePolicy
+		q.push(phrase)
+	}
+	// The phrases of the same priority keep their order.
+	want := []int{4, 6, 2, 5, 3}
+	if got := queuedIds(q); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("queue = %v, want %v", got, want)
+	}
+	for _, id := range want {
+		s.finishSpeaking()
+		if status := waitStatus(t, statuses); status != PhraseSpoken {
+			t.Errorf("phrase before %d finished as %s", id, status)
+		}
+		waitStarted(t, s, id)
+	}
+}
+
+func TestTrimDropsOldestOfLowestPriority(t *testing.T) {
+	s := newFakeSpeaker()
+	q := newSpeechQueue(s, 3)
+	defer q.stop()
+	statuses := make(map[int]chan PhraseStatus)
+	push := func(id int, priority Priority) bool {
+		statuses[id] = make(chan PhraseStatus, 1)
+		phrase := newTestPhrase(id, priority, statuses[id])
+		phrase.Policy = EnqueuePolicy
+		return q.push(phrase)
+	}
+	push(1, NormalPriority)
+	waitStarted(t, s, 1)
+	push(2, NormalPriority)
+	push(3, ChatterPriority)
+	push(4, NormalPriority)
+	// The current phrase is not counted, so the queue is full but nothing is dropped.
+	if got := queuedIds(q); fmt.Sprint(got) != fmt.Sprint([]int{2, 4, 3}) {
+		t.Fatalf("queue = %v, want [2 4 3]", got)
+	}
+	push(5, CriticalPriority)
+	if status := waitStatus(t, statuses[3]); status != PhraseDropped {
+		t.Errorf("chatter finished as %s", status)
+	}
+	push(6, NormalPriority)
+	if status := waitStatus(t, statuses[2]); status != PhraseDropped {
+		t.Errorf("oldest normal phrase finished as %s", status)
+	}
+	if push(7, ChatterPriority) {
+		t.Error("chatter was accepted by the full queue")
+	}
+	if status := waitStatus(t, statuses[7]); status != PhraseDropped {
+		t.Errorf("chatter finished as %s", status)
+	}
+	if got := queuedIds(q); fmt.Sprint(got) != fmt.Sprint([]int{5, 4, 6}) {
+		t.Errorf("queue = %v, want [5 4 6]", got)
+	}
+}
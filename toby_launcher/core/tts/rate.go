@@ -0,0 +1,60 @@
+package tts
+
+import "math"
+
+// RateScale maps the speech rate in words per minute, which is used by the configuration and
+// by the SpeechSynthesizer interface, to the native rate of a synthesizer. The native rates
+// MinRate, NormalRate and MaxRate are spoken at MinWpm, NormalWpm and MaxWpm words per minute,
+// and the rates between them are interpolated linearly.
+type RateScale struct {
+	MinWpm     int
+	NormalWpm  int
+	MaxWpm     int
+	MinRate    float64
+	NormalRate float64
+	MaxRate    float64
+}
+
+// WpmRateScale is the scale of the synthesizers whose native rate is in words per minute.
+var WpmRateScale = RateScale{
+	MinWpm:     80,
+	NormalWpm:  175,
+	MaxWpm:     1000,
+	MinRate:    80,
+	NormalRate: 175,
+	MaxRate:    1000,
+}
+
+// Clamp limits the speech rate to the rates the synthesizer can speak at.
+func (s RateScale) Clamp(wpm int) int {
+	return min(max(wpm, s.MinWpm), s.MaxWpm)
+}
+
+// FromWpm returns the native rate for the speech rate in words per minute.
+func (s RateScale) FromWpm(wpm int) float64 {
+	wpm = s.Clamp(wpm)
+	if wpm <= s.NormalWpm {
+		return interpolate(float64(wpm), float64(s.MinWpm), float64(s.NormalWpm), s.MinRate, s.NormalRate)
+	}
+	return interpolate(float64(wpm), float64(s.NormalWpm), float64(s.MaxWpm), s.NormalRate, s.MaxRate)
+}
+
+// ToWpm returns the speech rate in words per minute for the native rate.
+func (s RateScale) ToWpm(rate float64) int {
+	rate = min(max(rate, s.MinRate), s.MaxRate)
+	var wpm float64
+	if rate <= s.NormalRate {
+		wpm = interpolate(rate, s.MinRate, s.NormalRate, float64(s.MinWpm), float64(s.NormalWpm))
+	} else {
+		wpm = interpolate(rate, s.NormalRate, s.MaxRate, float64(s.NormalWpm), float64(s.MaxWpm))
+	}
+	return int(math.Round(wpm))
+}
+
+// interpolate maps the value from the range from..to to the range toFrom..toTo.
+func interpolate(value, from, to, toFrom, toTo float64) float64 {
+	if to == from {
+		return toFrom
+	}
+	return toFrom + (value-from)*(toTo-toFrom)/(to-from)
+}
@@ -9,35 +9,95 @@ import (
 type factoryFunc func() (SpeechSynthesizer, error)
 
 type factory struct {
+	name     string
 	create   factoryFunc
 	priority int
+	// isExplicit is set for the synthesizers used only when the user selects them,
+	// which are never switched to when another synthesizer fails.
+	isExplicit bool
 }
 
 var synthesizerFactories []factory
 
-func RegisterSynthesizer(f factoryFunc, priority int) {
+// RegisterSynthesizer registers the factory of the named synthesizer. Synthesizers with a lower
+// priority are preferred, unless the order is set in the configuration.
+func RegisterSynthesizer(name string, f factoryFunc, priority int) {
 	fact := factory{
+		name:     name,
 		create:   f,
 		priority: priority,
 	}
 	synthesizerFactories = append(synthesizerFactories, fact)
 }
 
-func GetAvailableSynthesizers(logger logger.Logger) []SpeechSynthesizer {
-	sort.Slice(synthesizerFactories, func(i, j int) bool {
-		return synthesizerFactories[i].priority < synthesizerFactories[j].priority
-	})
-	syns := make([]SpeechSynthesizer, 0, len(synthesizerFactories))
-	for _, factory := range synthesizerFactories {
-		s, err := factory.create()
-		if err == nil {
-			syns = append(syns, s)
-		} else {
-			logger.DebugError(apperrors.New(apperrors.Err, "Failed to initialize factory speech synthesizer: $error.", map[string]any{"error": err}))
+// RegisterExplicitSynthesizer registers the factory of the named synthesizer which does not speak,
+// such as one writing text. It must be registered only when the user has selected it or listed it
+// in the order, and it is left out of the failover.
+func RegisterExplicitSynthesizer(name string, f factoryFunc, priority int) {
+	fact := factory{
+		name:       name,
+		create:     f,
+		priority:   priority,
+		isExplicit: true,
+	}
+	synthesizerFactories = append(synthesizerFactories, fact)
+}
+
+// EngineStatus is the result of probing a registered speech engine.
+type EngineStatus struct {
+	Name string
+	// Err is the reason the engine is unavailable, nil if it is available.
+	Err error
+}
+
+func (s EngineStatus) IsAvailable() bool {
+	return s.Err == nil
+}
+
+// orderedFactories returns the factories in the order of the configuration,
+// followed by the factories missing from it by their priority.
+func orderedFactories(order []string) []factory {
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		if _, isFound := rank[name]; !isFound {
+			rank[name] = i
 		}
 	}
-	for _, s := range syns {
-		s.Release()
+	factories := make([]factory, len(synthesizerFactories))
+	copy(factories, synthesizerFactories)
+	sort.SliceStable(factories, func(i, j int) bool {
+		rankI, isRankedI := rank[factories[i].name]
+		rankJ, isRankedJ := rank[factories[j].name]
+		if isRankedI != isRankedJ {
+			return isRankedI
+		}
+		if isRankedI {
+			return rankI < rankJ
+		}
+		return factories[i].priority < factories[j].priority
+	})
+	return factories
+}
+
+// probeSynthesizers creates and releases a synthesizer of every factory except the one
+// that is already created, which is available.
+func probeSynthesizers(factories []factory, current string, logger logger.Logger) []EngineStatus {
+	statuses := make([]EngineStatus, 0, len(factories))
+	for _, factory := range factories {
+		status := EngineStatus{Name: factory.name}
+		if factory.name != current {
+			s, err := factory.create()
+			if err == nil {
+				s.Release()
+			} else {
+				status.Err = err
+				logger.DebugError(apperrors.New(apperrors.Err, "Failed to initialize factory speech synthesizer $synthesizer: $error.", map[string]any{
+					"synthesizer": factory.name,
+					"error":       err,
+				}))
+			}
+		}
+		statuses = append(statuses, status)
 	}
-	return syns
+	return statuses
 }
@@ -2,19 +2,69 @@ package tts
 
 import (
 	"fmt"
+	"sync"
 	"time"
 	"toby_launcher/apperrors"
 	"toby_launcher/config"
 	"toby_launcher/core/logger"
 )
 
+// Phrase is a text to be spoken. Zero values of Rate, Voice, Pitch and Volume mean
+// that the settings of the synthesizer are used.
 type Phrase struct {
-	Id      int
-	Text    string
-	Rate    int
-	Silence int
+	Id       int
+	Text     string
+	Rate     int
+	Silence  int
+	Voice    string
+	Pitch    int
+	Volume   int
+	Priority Priority
+	Policy   Policy
+	// Verbatim phrases are spoken without the pronunciation lexicon.
+	Verbatim bool
+	// Markup phrases are written in the speech markup described in markup.go.
+	Markup bool
+	// Language is the language of the text, such as "ru". If it is empty, the language is detected
+	// when the language detection is enabled.
+	Language string
+	// OnDone is called once when the phrase has been spoken, interrupted, dropped or has failed.
+	// It is called from the goroutine of the speech queue and must not wait for speech.
+	OnDone func(status PhraseStatus)
 }
 
+// Voice describes a voice of a synthesizer. Id is the value passed to SetVoice.
+type Voice struct {
+	Id       string
+	Name     string
+	Language string
+	Gender   string
+}
+
+// Capabilities describes which speech settings a synthesizer can change
+// and whether it understands the speech markup.
+type Capabilities uint
+
+const (
+	RateCapability Capabilities = 1 << iota
+	VoiceCapability
+	PitchCapability
+	VolumeCapability
+	// MarkupCapability synthesizers receive the markup of phrases as SSML, see SsmlText.
+	MarkupCapability
+)
+
+func (c Capabilities) Has(capability Capabilities) bool {
+	return c&capability != 0
+}
+
+const (
+	// MaxPitch is the highest pitch, 50 is the normal pitch of most synthesizers.
+	MaxPitch = 100
+	// MaxVolume is the loudest volume in percent.
+	MaxVolume = 100
+)
+
 type SpeechSynthesizer interface {
 	Name() string
 	CreateNew() (SpeechSynthesizer, error)
@@ -22,19 +72,69 @@ type SpeechSynthesizer interface {
 	Speak(*Phrase) error
 	Stop() error
 	IsSpeaking() (bool, error)
+	Capabilities() Capabilities
+	// SetSpeechRate and GetSpeechRate use words per minute, which RateScale maps to the native rate.
 	SetSpeechRate(rate int) error
 	GetSpeechRate() int
-	SupportsChangingSpeechRate() bool
+	RateScale() RateScale
+	SetVoice(voice string) error
+	GetVoice() string
+	Voices() ([]Voice, error)
+	SetPitch(pitch int) error
+	GetPitch() int
+	SetVolume(volume int) error
+	GetVolume() int
 	SetLogger(l logger.Logger)
 	LogError(err error)
 }
 
+// CompletionReporter is implemented by synthesizers that report the end of every phrase,
+// so the speech queue does not have to poll IsSpeaking.
+type CompletionReporter interface {
+	// SetCompletionHandler sets the function called with the id of the phrase when its speech ends
+	// or is stopped. The function does not block and can be called from any goroutine.
+	SetCompletionHandler(f func(phraseId int))
+}
+
+// BaseSynthesizer provides the defaults for synthesizers that can change only the speech rate.
 type BaseSynthesizer struct {
 	logger logger.Logger
 }
 
-func (b *BaseSynthesizer) SupportsChangingSpeechRate() bool {
-	return true
+func (b *BaseSynthesizer) Capabilities() Capabilities {
+	return RateCapability
+}
+
+func (b *BaseSynthesizer) RateScale() RateScale {
+	return WpmRateScale
+}
+
+func (b *BaseSynthesizer) SetVoice(voice string) error {
+	return fmt.Errorf("changing the voice is not supported")
+}
+
+func (b *BaseSynthesizer) GetVoice() string {
+	return ""
+}
+
+func (b *BaseSynthesizer) Voices() ([]Voice, error) {
+	return []Voice{}, nil
+}
+
+func (b *BaseSynthesizer) SetPitch(pitch int) error {
+	return fmt.Errorf("changing the pitch is not supported")
+}
+
+func (b *BaseSynthesizer) GetPitch() int {
+	return 0
+}
+
+func (b *BaseSynthesizer) SetVolume(volume int) error {
+	return fmt.Errorf("changing the volume is not supported")
+}
+
+func (b *BaseSynthesizer) GetVolume() int {
+	return 0
 }
 
 func (b *BaseSynthesizer) SetLogger(l logger.Logger) {
@@ -47,104 +147,233 @@ func (b *BaseSynthesizer) LogError(err error) {
 	}
 }
 
+// TtsManager speaks phrases through the current synthesizer.
+// Phrases are queued and spoken one after another in a separate goroutine, so every access
+// to the current synthesizer is guarded by the mutex.
 type TtsManager struct {
-	logger                logger.Logger
-	currentSynthesizer    SpeechSynthesizer
-	availableSynthesizers []SpeechSynthesizer
-	phraseCounter         int
-	config                *config.TtsConfig
+	logger             logger.Logger
+	mu                 sync.Mutex
+	currentSynthesizer SpeechSynthesizer
+	factories          []factory
+	// engineStatuses are probed when they are needed first, so only the selected synthesizer is created at startup.
+	engineStatuses []EngineStatus
+	phraseCounter  int
+	config         *config.TtsConfig
+	queue          *speechQueue
+	// outputsMu guards the outputs, which are written from the goroutine of the queue.
+	outputsMu sync.Mutex
+	outputs   []Output
+	health    *health
+	// announcements are the messages about the synthesizers queued after the mutex is unlocked.
+	announcements []*Phrase
+	lexicon       []lexiconRule
+	// languageRules detect the languages of phrases to switch the voice.
+	languageRules []languageRule
+	normalizers   []Normalizer
+	// voiceLanguage is the language of the current voice, looked up when a rule of the lexicon needs it.
+	voiceLanguage   string
+	isLanguageKnown bool
 }
 
 func NewTtsManager(cfg *config.TtsConfig, logger logger.Logger) (*TtsManager, error) {
 	if logger == nil {
 		return nil, fmt.Errorf("logger not specified")
 	}
-	syns := GetAvailableSynthesizers(logger)
-	if len(syns) == 0 {
-		return nil, apperrors.New(apperrors.ErrSpeech, "No available speech synthesizers.", nil)
-	}
 	manager := &TtsManager{
-		logger:                logger,
-		availableSynthesizers: syns,
-		phraseCounter:         0,
-		config:                cfg,
+		logger:        logger,
+		factories:     orderedFactories(cfg.EngineOrder),
+		phraseCounter: 0,
+		config:        cfg,
+		health:        newHealth(),
+		lexicon:       compileLexicon(cfg.Lexicon),
+		languageRules: compileLanguageRules(cfg.LanguageDetection),
+		normalizers:   createNormalizers(cfg.Normalization),
 	}
+	// The queue is created first, as the synthesizers report the end of phrases to it.
+	manager.queue = newSpeechQueue(manager, cfg.MaxQueueLength)
 	if err := manager.ApplyConfig(); err != nil {
+		manager.queue.stop()
 		return nil, err
 	}
+	manager.openOutputs()
 	return manager, nil
 }
 
 func (m *TtsManager) Release() {
+	m.queue.stop()
+	m.releaseOutputs()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.currentSynthesizer != nil {
 		m.currentSynthesizer.Release()
 		m.currentSynthesizer = nil
 	}
-	m.availableSynthesizers = nil
+	m.engineStatuses = nil
 }
 
+// Wait waits up to timeout milliseconds until all queued phrases are spoken.
 func (m *TtsManager) Wait(timeout int) error {
-	waiting := 0
-	waitMs := 200
-	if m.currentSynthesizer == nil {
-		return nil
-	}
-	for {
-		isSpeaking, err := m.currentSynthesizer.IsSpeaking()
-		if err != nil {
-			return err
-		}
-		if !isSpeaking {
-			return nil
-		}
-		time.Sleep(time.Duration(waitMs) * time.Millisecond)
-		waiting += waitMs
-		if waiting >= timeout {
-			return nil
-		}
+	m.queue.waitIdle(time.Duration(timeout) * time.Millisecond)
+	return nil
+}
+
+// WaitFor waits up to timeout milliseconds until the phrase with the id has finished.
+// A phrase that is not queued anymore has already finished.
+func (m *TtsManager) WaitFor(id, timeout int) error {
+	if !m.queue.waitFor(id, time.Duration(timeout)*time.Millisecond) {
+		return apperrors.New(apperrors.ErrSpeech, "The phrase $id has not been spoken in $timeout ms.", map[string]any{"id": id, "timeout": timeout})
 	}
+	return nil
+}
+
+// OnIdle adds the function called every time the last queued phrase has finished.
+// It is called from the goroutine of the speech queue and must not wait for speech.
+func (m *TtsManager) OnIdle(f func()) {
+	m.queue.addIdleCallback(f)
 }
 
 func (m *TtsManager) NewPhrase(text string, rate, silence int) *Phrase {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.newPhrase(text, rate, silence)
+}
+
+func (m *TtsManager) newPhrase(text string, rate, silence int) *Phrase {
 	m.phraseCounter++
 	return &Phrase{
-		Id:      m.phraseCounter,
-		Text:    text,
-		Rate:    rate,
-		Silence: silence,
+		Id:       m.phraseCounter,
+		Text:     text,
+		Rate:     rate,
+		Silence:  silence,
+		Priority: NormalPriority,
 	}
 }
 
-func (m *TtsManager) Speak(text string) {
+// Speak speaks a message of the user interface, interrupting less important speech.
+// It returns the id of the phrase, which can be waited for with WaitFor.
+func (m *TtsManager) Speak(text string) int {
+	return m.SpeakWithPriority(text, UiPriority)
+}
+
+func (m *TtsManager) SpeakWithPriority(text string, priority Priority) int {
 	phrase := m.NewPhrase(text, 0, 0)
+	phrase.Priority = priority
 	m.SpeakPhrase(phrase)
+	return phrase.Id
 }
 
+// SpeakMarkup speaks the text written in the speech markup with the priority.
+func (m *TtsManager) SpeakMarkup(text string, priority Priority) int {
+	phrase := m.NewPhrase(text, 0, 0)
+	phrase.Priority = priority
+	phrase.Markup = true
+	m.SpeakPhrase(phrase)
+	return phrase.Id
+}
+
+// SpeakPhrase queues the phrase according to its priority and policy.
 func (m *TtsManager) SpeakPhrase(phrase *Phrase) {
+	if !m.queue.push(phrase) {
+		m.logger.DebugPrintf("speech dropped: %s\r\n", phrase.Text)
+	}
+}
+
+// Flush stops the current phrase and drops all queued phrases.
+func (m *TtsManager) Flush() {
+	m.queue.flush()
+}
+
+// prepare writes the phrase to the outputs and splits it into the parts spoken one after another.
+func (m *TtsManager) prepare(phrase *Phrase) []*Phrase {
+	if phrase.Markup {
+		m.writeOutputs(StripMarkup(phrase.Text))
+	} else {
+		m.writeOutputs(phrase.Text)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.splitByLanguage(phrase)
+}
+
+// speakNow speaks a part of a phrase. If the synthesizer keeps failing,
+// the phrase is spoken by the next synthesizer that works.
+func (m *TtsManager) speakNow(phrase *Phrase) error {
+	defer m.pushAnnouncements()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.currentSynthesizer == nil {
+		return apperrors.New(apperrors.ErrSpeech, "No speech synthesizer is initialized.", nil)
+	}
+	m.tryRestorePreferred()
+	err := m.currentSynthesizer.Speak(m.pronounce(phrase))
+	if m.health.record(m.currentSynthesizer.Name(), err) {
+		if err != nil {
+			return apperrors.New(apperrors.ErrSpeech, err.Error(), nil)
+		}
+		return nil
+	}
+	m.logger.Error(apperrors.New(apperrors.ErrSpeech, err.Error(), nil))
+	if !m.failover() {
+		return apperrors.New(apperrors.ErrSpeech, "No speech synthesizer works.", nil)
+	}
+	if err := m.currentSynthesizer.Speak(m.pronounce(phrase)); err != nil {
+		m.health.record(m.currentSynthesizer.Name(), err)
+		return apperrors.New(apperrors.ErrSpeech, err.Error(), nil)
+	}
+	return nil
+}
+
+func (m *TtsManager) stopNow() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.currentSynthesizer == nil {
-		m.logger.Error(apperrors.New(apperrors.ErrSpeech, "No speech synthesizer is initialized.", nil))
 		return
 	}
-	if err := m.currentSynthesizer.Speak(phrase); err != nil {
-		m.logger.Error(apperrors.New(apperrors.ErrSpeech, err.Error(), nil))
+	if err := m.currentSynthesizer.Stop(); err != nil {
+		m.logger.DebugError(apperrors.New(apperrors.ErrSpeech, err.Error(), nil))
 	}
 }
 
+func (m *TtsManager) isSpeakingNow() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.currentSynthesizer == nil {
+		return false
+	}
+	isSpeaking, err := m.currentSynthesizer.IsSpeaking()
+	if err != nil {
+		m.logger.DebugError(apperrors.New(apperrors.ErrSpeech, err.Error(), nil))
+		return false
+	}
+	return isSpeaking
+}
+
+func (m *TtsManager) reportsCompletion() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.currentSynthesizer.(CompletionReporter)
+	return ok
+}
+
+func (m *TtsManager) logError(err error) {
+	m.logger.Error(err)
+}
+
 func (m *TtsManager) ApplyConfig() error {
-	baseSynthName := m.availableSynthesizers[0].Name()
-	synthName := m.config.SynthesizerName
-	if m.currentSynthesizer == nil && synthName == "" {
-		synthName = baseSynthName
-	}
-	if err := m.SetSynthesizer(synthName); err != nil {
-		m.logger.Error(err)
-		if synthName != baseSynthName {
-			if err := m.SetSynthesizer(baseSynthName); err != nil {
-				return err
-			}
+	isSelected := false
+	if synthName := m.config.SynthesizerName; synthName != "" {
+		if err := m.SetSynthesizer(synthName); err != nil {
+			m.logger.Error(err)
+		} else {
+			isSelected = true
+		}
+	}
+	if !isSelected {
+		if err := m.selectFirstAvailable(); err != nil {
+			return err
 		}
 	}
-	if m.currentSynthesizer.SupportsChangingSpeechRate() {
+	if m.currentSynthesizer.Capabilities().Has(RateCapability) {
 		if err := m.SetSpeechRate(m.config.SpeechRate); err != nil {
 			return err
 		}
@@ -152,60 +381,252 @@ func (m *TtsManager) ApplyConfig() error {
 	return nil
 }
 
+// selectFirstAvailable selects the first synthesizer in the order that can be created.
+// The synthesizers after it are not probed.
+func (m *TtsManager) selectFirstAvailable() error {
+	for _, factory := range m.factories {
+		err := m.SetSynthesizer(factory.name)
+		if err == nil {
+			return nil
+		}
+		m.logger.DebugError(err)
+	}
+	return apperrors.New(apperrors.ErrSpeech, "No available speech synthesizers.", nil)
+}
+
+// SetSynthesizer selects the synthesizer preferred by the user.
 func (m *TtsManager) SetSynthesizer(synthName string) error {
+	if m.queue != nil {
+		m.queue.flush()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.setSynthesizer(synthName); err != nil {
+		return err
+	}
+	m.config.SynthesizerName = synthName
+	m.health.reset(synthName)
+	return nil
+}
+
+// setSynthesizer replaces the current synthesizer without changing the configuration.
+func (m *TtsManager) setSynthesizer(synthName string) error {
 	if m.currentSynthesizer != nil && m.currentSynthesizer.Name() == synthName {
 		return nil
 	}
-	synth, isFound := m.findSynthesizer(synthName)
+	factory, isFound := m.findFactory(synthName)
 	if !isFound {
 		return apperrors.New(apperrors.ErrSpeech, "The speech synthesizer \"$synthesizer\" is missing.", map[string]any{"synthesizer": synthName})
 	}
-	if m.currentSynthesizer != nil {
-		m.currentSynthesizer.Release()
-	}
-	newSynth, err := synth.CreateNew()
+	newSynth, err := factory.create()
 	if err != nil {
 		return apperrors.New(apperrors.ErrSpeech, err.Error(), nil)
 	}
-	if m.config.SpeechRate > 0 && newSynth.SupportsChangingSpeechRate() {
-		if err := newSynth.SetSpeechRate(m.config.SpeechRate); err != nil {
-			return apperrors.New(apperrors.Err, err.Error(), nil)
-		}
+	if err := m.applySettings(newSynth); err != nil {
+		newSynth.Release()
+		return err
 	}
 	newSynth.SetLogger(m.logger)
+	if reporter, ok := newSynth.(CompletionReporter); ok {
+		reporter.SetCompletionHandler(m.queue.complete)
+	}
+	if m.currentSynthesizer != nil {
+		m.currentSynthesizer.Release()
+	}
 	m.currentSynthesizer = newSynth
-	m.config.SynthesizerName = newSynth.Name()
-	m.phraseCounter = 0
+	m.isLanguageKnown = false
 	return nil
 }
 
+// SetSpeechRate sets the speech rate in words per minute, limited to the rates the current synthesizer
+// can speak at. The configuration keeps the rate in words per minute rather than the native rate
+// the synthesizer rounds it to, so another synthesizer speaks at the same speed.
 func (m *TtsManager) SetSpeechRate(rate int) error {
-	if rate < 0 || rate == m.currentSynthesizer.GetSpeechRate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rate < 0 {
 		return nil
 	}
+	if !m.currentSynthesizer.Capabilities().Has(RateCapability) {
+		return m.unsupported("speech rate")
+	}
 	if rate == 0 {
 		rate = m.currentSynthesizer.GetSpeechRate()
 	}
+	if rate > 0 {
+		rate = m.currentSynthesizer.RateScale().Clamp(rate)
+	}
 	if err := m.currentSynthesizer.SetSpeechRate(rate); err != nil {
-		return err
+		return apperrors.New(apperrors.ErrSpeech, err.Error(), nil)
+	}
+	m.config.SpeechRate = rate
+	return nil
+}
+
+// RateScale returns the rate scale of the current synthesizer.
+func (m *TtsManager) RateScale() RateScale {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.currentSynthesizer == nil {
+		return WpmRateScale
+	}
+	return m.currentSynthesizer.RateScale()
+}
+
+// applySettings applies the speech settings of the configuration the synthesizer supports.
+func (m *TtsManager) applySettings(synth SpeechSynthesizer) error {
+	caps := synth.Capabilities()
+	if m.config.SpeechRate > 0 && caps.Has(RateCapability) {
+		if err := synth.SetSpeechRate(synth.RateScale().Clamp(m.config.SpeechRate)); err != nil {
+			return apperrors.New(apperrors.Err, err.Error(), nil)
+		}
+	}
+	if m.config.Voice != "" && caps.Has(VoiceCapability) {
+		if err := synth.SetVoice(m.config.Voice); err != nil {
+			return apperrors.New(apperrors.Err, err.Error(), nil)
+		}
+	}
+	if m.config.Pitch > 0 && caps.Has(PitchCapability) {
+		if err := synth.SetPitch(m.config.Pitch); err != nil {
+			return apperrors.New(apperrors.Err, err.Error(), nil)
+		}
+	}
+	if m.config.Volume > 0 && caps.Has(VolumeCapability) {
+		if err := synth.SetVolume(m.config.Volume); err != nil {
+			return apperrors.New(apperrors.Err, err.Error(), nil)
+		}
+	}
+	return nil
+}
+
+// Capabilities returns the capabilities of the current synthesizer.
+func (m *TtsManager) Capabilities() Capabilities {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.currentSynthesizer == nil {
+		return 0
+	}
+	return m.currentSynthesizer.Capabilities()
+}
+
+func (m *TtsManager) unsupported(setting string) error {
+	return apperrors.New(apperrors.ErrSpeech, "The speech synthesizer \"$synthesizer\" can not change the $setting.", map[string]any{
+		"synthesizer": m.currentSynthesizer.Name(),
+		"setting":     setting,
+	})
+}
+
+func (m *TtsManager) SetVoice(voice string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.currentSynthesizer.Capabilities().Has(VoiceCapability) {
+		return m.unsupported("voice")
+	}
+	if err := m.currentSynthesizer.SetVoice(voice); err != nil {
+		return apperrors.New(apperrors.ErrSpeech, err.Error(), nil)
+	}
+	m.config.Voice = m.currentSynthesizer.GetVoice()
+	m.isLanguageKnown = false
+	return nil
+}
+
+// Voices returns the voices of the current synthesizer.
+func (m *TtsManager) Voices() ([]Voice, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.currentSynthesizer.Capabilities().Has(VoiceCapability) {
+		return []Voice{}, nil
+	}
+	voices, err := m.currentSynthesizer.Voices()
+	if err != nil {
+		return nil, apperrors.New(apperrors.ErrSpeech, "Failed to get the voices: $error", map[string]any{"error": err})
+	}
+	return voices, nil
+}
+
+// SetPitch sets the pitch from 1 to MaxPitch, 0 restores the default pitch of the synthesizer.
+func (m *TtsManager) SetPitch(pitch int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.currentSynthesizer.Capabilities().Has(PitchCapability) {
+		return m.unsupported("pitch")
+	}
+	if err := m.currentSynthesizer.SetPitch(pitch); err != nil {
+		return apperrors.New(apperrors.ErrSpeech, err.Error(), nil)
 	}
-	m.config.SpeechRate = m.currentSynthesizer.GetSpeechRate()
+	m.config.Pitch = pitch
 	return nil
 }
 
-func (m *TtsManager) findSynthesizer(synthName string) (SpeechSynthesizer, bool) {
-	var synth SpeechSynthesizer
-	isFound := false
-	for _, s := range m.availableSynthesizers {
-		if s.Name() == synthName {
-			synth = s
-			isFound = true
-			break
+// SetVolume sets the volume from 1 to MaxVolume percent, 0 restores the default volume of the synthesizer.
+func (m *TtsManager) SetVolume(volume int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.currentSynthesizer.Capabilities().Has(VolumeCapability) {
+		return m.unsupported("volume")
+	}
+	if err := m.currentSynthesizer.SetVolume(volume); err != nil {
+		return apperrors.New(apperrors.ErrSpeech, err.Error(), nil)
+	}
+	m.config.Volume = volume
+	return nil
+}
+
+func (m *TtsManager) findFactory(synthName string) (factory, bool) {
+	for _, f := range m.factories {
+		if f.name == synthName {
+			return f, true
 		}
 	}
-	return synth, isFound
+	return factory{}, false
 }
 
-func (m *TtsManager) AvailableSynthesizers() []SpeechSynthesizer {
-	return m.availableSynthesizers
+// AvailableSynthesizers returns the names of the synthesizers found available by the last probe.
+func (m *TtsManager) AvailableSynthesizers() []string {
+	names := make([]string, 0, len(m.factories))
+	for _, status := range m.EngineStatuses() {
+		if status.IsAvailable() {
+			names = append(names, status.Name)
+		}
+	}
+	return names
+}
+
+// EngineStatuses returns the results of the last probe of the registered synthesizers,
+// probing them if they have not been probed yet.
+func (m *TtsManager) EngineStatuses() []EngineStatus {
+	m.mu.Lock()
+	statuses := append([]EngineStatus(nil), m.engineStatuses...)
+	m.mu.Unlock()
+	if statuses == nil {
+		statuses = m.probe()
+	}
+	return statuses
+}
+
+// Rescan probes the registered synthesizers again, so the engines installed or started
+// after the launch can be selected. The failing synthesizers are given another chance.
+func (m *TtsManager) Rescan() []EngineStatus {
+	statuses := m.probe()
+	m.mu.Lock()
+	m.health = newHealth()
+	m.mu.Unlock()
+	return statuses
+}
+
+// probe probes the registered synthesizers and stores their statuses.
+// Creating a synthesizer may take seconds, so the phrases are spoken meanwhile without waiting for the lock.
+func (m *TtsManager) probe() []EngineStatus {
+	m.mu.Lock()
+	factories := append([]factory(nil), m.factories...)
+	current := ""
+	if m.currentSynthesizer != nil {
+		current = m.currentSynthesizer.Name()
+	}
+	m.mu.Unlock()
+	statuses := probeSynthesizers(factories, current, m.logger)
+	m.mu.Lock()
+	m.engineStatuses = statuses
+	m.mu.Unlock()
+	return append([]EngineStatus(nil), statuses...)
 }
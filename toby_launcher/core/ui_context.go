@@ -15,18 +15,30 @@ type UiContext struct {
 	CommandRegistry *CommandRegistry
 	Logger          logger.Logger
 	TtsManager      *tts.TtsManager
+	screen          screen
 }
 
 func (ui *UiContext) DisplayText(txt string) {
-	if err := ui.Console.Write(utils.WrapText(txt, 80)); err != nil {
-		fmt.Println(ui.ErrorHandler.Handle(err))
-	}
+	ui.display(PlainText, txt)
+}
+
+// DisplayHint displays a hint on what to do, such as "Make your choice.",
+// which is spoken only at the verbose level of self-voicing.
+func (ui *UiContext) DisplayHint(txt string) {
+	ui.display(HintText, txt)
 }
 
 func (ui *UiContext) DisplayError(err error) {
 	msg := ui.ErrorHandler.Handle(err)
 	if msg != "" {
-		ui.DisplayText(fmt.Sprintf("%s\r\n", msg))
+		ui.display(ImportantText, fmt.Sprintf("%s\r\n", msg))
+	}
+}
+
+func (ui *UiContext) display(kind TextKind, txt string) {
+	ui.screen.add(kind, txt)
+	if err := ui.Console.Write(utils.WrapText(txt, 80)); err != nil {
+		fmt.Println(ui.ErrorHandler.Handle(err))
 	}
 }
 
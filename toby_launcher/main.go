@@ -10,7 +10,7 @@ import (
 	"toby_launcher/core/game"
 	"toby_launcher/core/logger"
 	"toby_launcher/core/tts"
-	_ "toby_launcher/speech_engines"
+	"toby_launcher/speech_engines"
 )
 
 func main() {
@@ -29,6 +29,9 @@ func main() {
 	if err := cfg.Load(cfg.Paths.ConfigFilePath()); err != nil {
 		logger.Error(err)
 	}
+	if err := cfg.LoadLexicon(); err != nil {
+		logger.Error(err)
+	}
 	defer func() {
 		if err := cfg.Save(); err != nil {
 			logger.Error(err)
@@ -48,6 +51,7 @@ func main() {
 			err = consoleErr
 		}
 	}()
+	speech_engines.Configure(cfg.Tts, console)
 	ttsManager, err := tts.NewTtsManager(cfg.Tts, logger)
 	if err != nil {
 		logger.Error(err)
@@ -92,6 +96,7 @@ func runMainLoop(appCtx *core.AppContext, uiCtx *core.UiContext, startState core
 		currentState.Display(appCtx, uiCtx)
 		input := ""
 		if currentState.RequiresInput() {
+			uiCtx.SpeakScreen(appCtx, currentState)
 			buf, inputErr := uiCtx.Console.Read()
 			uiCtx.DisplayError(inputErr)
 			if appErr, ok := inputErr.(*apperrors.AppError); ok && appErr.Code == apperrors.ErrEOF {
@@ -9,9 +9,11 @@ import (
 )
 
 func init() {
-	tts.RegisterSynthesizer(NewSynthesizer, 0)
+	tts.RegisterSynthesizer(engineName, NewSynthesizer, 0)
 }
 
+const engineName = "NsSpeech"
+
 type Synthesizer struct {
 	tts.BaseSynthesizer
 	speechRate int
@@ -35,7 +37,7 @@ func NewSynthesizer() (tts.SpeechSynthesizer, error) {
 }
 
 func (s *Synthesizer) Name() string {
-	return "NsSpeech"
+	return engineName
 }
 
 func (s *Synthesizer) CreateNew() (tts.SpeechSynthesizer, error) {
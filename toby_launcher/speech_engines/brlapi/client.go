@@ -0,0 +1,347 @@
+package brlapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// protocolVersion is the version of the BrlAPI protocol spoken by the client.
+const protocolVersion = 8
+
+const (
+	socketDir    = "/var/lib/BrlAPI"
+	basePort     = 4101
+	maxPacket    = 4096
+	dialTimeout  = 500 * time.Millisecond
+	replyTimeout = 5 * time.Second
+)
+
+// Types of the packets.
+const (
+	packetVersion         = 'v'
+	packetAuth            = 'a'
+	packetDisplaySize     = 's'
+	packetEnterTtyMode    = 't'
+	packetLeaveTtyMode    = 'L'
+	packetKey             = 'k'
+	packetIgnoreKeyRanges = 'm'
+	packetAcceptKeyRanges = 'u'
+	packetWrite           = 'w'
+	packetAck             = 'A'
+	packetError           = 'e'
+	packetException       = 'E'
+)
+
+// Methods of authentication.
+const (
+	authNone        = 'N'
+	authKey         = 'K'
+	authCredentials = 'C'
+)
+
+// Flags of the write packet.
+const (
+	writeRegion  = 0x02
+	writeText    = 0x04
+	writeCursor  = 0x20
+	writeCharset = 0x40
+)
+
+// Codes of the keys of the commands panning the display, which have no arguments and flags.
+const (
+	keyTypeCommand = 0x20000000
+	keyPanLeft     = keyTypeCommand | 23
+	keyPanRight    = keyTypeCommand | 24
+	keyCodeMask    = 0xFFFFFFFF
+)
+
+type packet struct {
+	kind    uint32
+	payload []byte
+}
+
+// address returns the network and the address of the server for the host, which has the form
+// of the BRLAPI_HOST environment variable: ":N" is the local server N, "host:N" is the TCP port 4101+N.
+func address(host string) (string, string, error) {
+	name, port, found := strings.Cut(host, ":")
+	num := 0
+	if found && port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 0 {
+			return "", "", fmt.Errorf("invalid braille host \"%s\"", host)
+		}
+		num = n
+	}
+	if name == "" {
+		if runtime.GOOS == "windows" {
+			name = "127.0.0.1"
+		} else {
+			return "unix", filepath.Join(socketDir, strconv.Itoa(num)), nil
+		}
+	}
+	return "tcp", net.JoinHostPort(name, strconv.Itoa(basePort+num)), nil
+}
+
+// client talks the BrlAPI protocol to BRLTTY. After the connection is set up, the packets
+// are read in a separate goroutine, which passes the keys and the replies to the channels.
+type client struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	replies chan packet
+	keys    chan uint64
+	done    chan struct{}
+}
+
+func dial(host, keyFile string) (*client, error) {
+	network, addr, err := address(host)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout(network, addr, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &client{
+		conn:    conn,
+		replies: make(chan packet),
+		keys:    make(chan uint64, 16),
+		done:    make(chan struct{}),
+	}
+	conn.SetDeadline(time.Now().Add(replyTimeout))
+	if err := c.handshake(keyFile); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	go c.read()
+	return c, nil
+}
+
+func (c *client) readPacket() (packet, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return packet{}, err
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	if size > maxPacket {
+		return packet{}, fmt.Errorf("braille packet is too large")
+	}
+	p := packet{kind: binary.BigEndian.Uint32(header[4:]), payload: make([]byte, size)}
+	if _, err := io.ReadFull(c.conn, p.payload); err != nil {
+		return packet{}, err
+	}
+	return p, nil
+}
+
+func (c *client) writePacket(kind uint32, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(data[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(data[4:], kind)
+	_, err := c.conn.Write(append(data, payload...))
+	return err
+}
+
+func unexpectedPacket(p packet) error {
+	if p.kind == packetError && len(p.payload) >= 4 {
+		return fmt.Errorf("braille server error %d", binary.BigEndian.Uint32(p.payload))
+	}
+	return fmt.Errorf("unexpected braille packet '%c'", rune(p.kind))
+}
+
+// handshake exchanges the versions and authenticates the client with the first method
+// offered by the server that succeeds.
+func (c *client) handshake(keyFile string) error {
+	p, err := c.readPacket()
+	if err != nil {
+		return err
+	}
+	if p.kind != packetVersion {
+		return unexpectedPacket(p)
+	}
+	if err := c.writePacket(packetVersion, uint32s(protocolVersion)); err != nil {
+		return err
+	}
+	if p, err = c.readPacket(); err != nil {
+		return err
+	}
+	if p.kind == packetAck {
+		return nil
+	}
+	if p.kind != packetAuth {
+		return unexpectedPacket(p)
+	}
+	err = fmt.Errorf("no supported braille authentication method")
+	for i := 0; i+4 <= len(p.payload); i += 4 {
+		switch binary.BigEndian.Uint32(p.payload[i:]) {
+		case authNone:
+			return nil
+		case authKey:
+			key, readErr := os.ReadFile(keyFile)
+			if readErr != nil {
+				err = readErr
+				continue
+			}
+			err = c.authenticate(append(uint32s(authKey), bytes.TrimSpace(key)...))
+		case authCredentials:
+			err = c.authenticate(uint32s(authCredentials))
+		default:
+			continue
+		}
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (c *client) authenticate(payload []byte) error {
+	if err := c.writePacket(packetAuth, payload); err != nil {
+		return err
+	}
+	p, err := c.readPacket()
+	if err != nil {
+		return err
+	}
+	if p.kind != packetAck {
+		return unexpectedPacket(p)
+	}
+	return nil
+}
+
+func (c *client) read() {
+	defer close(c.done)
+	for {
+		p, err := c.readPacket()
+		if err != nil {
+			return
+		}
+		switch p.kind {
+		case packetKey:
+			if len(p.payload) >= 8 {
+				select {
+				case c.keys <- binary.BigEndian.Uint64(p.payload):
+				default:
+				}
+			}
+		case packetException:
+			// Exceptions report the errors of the packets which have no reply, such as the written text.
+		default:
+			select {
+			case c.replies <- p:
+			case <-time.After(replyTimeout):
+			}
+		}
+	}
+}
+
+func (c *client) isClosed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// request sends the packet and waits for the reply.
+func (c *client) request(kind uint32, payload []byte) (packet, error) {
+	if err := c.writePacket(kind, payload); err != nil {
+		return packet{}, err
+	}
+	select {
+	case p := <-c.replies:
+		if p.kind == packetError {
+			return p, unexpectedPacket(p)
+		}
+		return p, nil
+	case <-c.done:
+		return packet{}, fmt.Errorf("braille server closed the connection")
+	case <-time.After(replyTimeout):
+		return packet{}, fmt.Errorf("braille server does not respond")
+	}
+}
+
+func (c *client) expectAck(kind uint32, payload []byte) error {
+	p, err := c.request(kind, payload)
+	if err != nil {
+		return err
+	}
+	if p.kind != packetAck {
+		return unexpectedPacket(p)
+	}
+	return nil
+}
+
+// enterTtyMode takes the display over for the terminal of the launcher. The keys are passed
+// to the client as commands, only the panning keys are accepted and the others are left to BRLTTY.
+func (c *client) enterTtyMode(ttys []uint32) error {
+	payload := uint32s(append([]uint32{uint32(len(ttys))}, ttys...)...)
+	// The empty driver name requests the keys as commands.
+	payload = append(payload, 0)
+	if err := c.expectAck(packetEnterTtyMode, payload); err != nil {
+		return err
+	}
+	if err := c.expectAck(packetIgnoreKeyRanges, uint64s(0, ^uint64(0))); err != nil {
+		return err
+	}
+	return c.expectAck(packetAcceptKeyRanges, uint64s(keyPanLeft, keyPanLeft, keyPanRight, keyPanRight))
+}
+
+// displaySize returns the number of the cells of the display.
+func (c *client) displaySize() (int, error) {
+	p, err := c.request(packetDisplaySize, nil)
+	if err != nil {
+		return 0, err
+	}
+	if p.kind != packetDisplaySize || len(p.payload) < 8 {
+		return 0, unexpectedPacket(p)
+	}
+	return int(binary.BigEndian.Uint32(p.payload) * binary.BigEndian.Uint32(p.payload[4:])), nil
+}
+
+// write shows the text, which must have exactly as many characters as the display has cells.
+func (c *client) write(text string, cells int) error {
+	payload := uint32s(writeRegion|writeText|writeCursor|writeCharset, 1, uint32(cells), uint32(len(text)))
+	payload = append(payload, text...)
+	// The cursor is hidden.
+	payload = append(payload, uint32s(0)...)
+	charset := "UTF-8"
+	payload = append(payload, byte(len(charset)))
+	payload = append(payload, charset...)
+	return c.writePacket(packetWrite, payload)
+}
+
+func (c *client) close() {
+	if !c.isClosed() {
+		c.writePacket(packetLeaveTtyMode, nil)
+	}
+	c.conn.Close()
+}
+
+func uint32s(values ...uint32) []byte {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint32(data[4*i:], v)
+	}
+	return data
+}
+
+func uint64s(values ...uint64) []byte {
+	data := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint64(data[8*i:], v)
+	}
+	return data
+}
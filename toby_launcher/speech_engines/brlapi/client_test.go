@@ -0,0 +1,231 @@
+package brlapi
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"toby_launcher/config"
+)
+
+// fakeServer is a BRLTTY with a display of cells cells, which authenticates the clients
+// with the key and passes the packets they send to the test.
+type fakeServer struct {
+	t        *testing.T
+	listener net.Listener
+	key      string
+	cells    uint32
+	packets  chan packet
+	conns    chan net.Conn
+}
+
+func newFakeServer(t *testing.T, key string, cells uint32) *fakeServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	port := listener.Addr().(*net.TCPAddr).Port
+	keyFile := filepath.Join(t.TempDir(), "brlapi.key")
+	if err := os.WriteFile(keyFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	previous := settings
+	Configure(config.BrailleOutput{
+		Host:    "127.0.0.1:" + strconv.Itoa(port-basePort),
+		KeyFile: keyFile,
+	})
+	t.Cleanup(func() { Configure(previous) })
+	s := &fakeServer{
+		t:        t,
+		listener: listener,
+		key:      key,
+		cells:    cells,
+		packets:  make(chan packet, 16),
+		conns:    make(chan net.Conn, 1),
+	}
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+	send := func(kind uint32, payload []byte) {
+		data := uint32s(uint32(len(payload)), kind)
+		conn.Write(append(data, payload...))
+	}
+	send(packetVersion, uint32s(protocolVersion))
+	if p, err := readServerPacket(conn); err != nil || p.kind != packetVersion {
+		return
+	}
+	send(packetAuth, uint32s(authKey))
+	p, err := readServerPacket(conn)
+	if err != nil {
+		return
+	}
+	s.packets <- p
+	if p.kind != packetAuth || string(p.payload) != string(append(uint32s(authKey), s.key...)) {
+		send(packetError, uint32s(4))
+		return
+	}
+	send(packetAck, nil)
+	s.conns <- conn
+	for {
+		p, err := readServerPacket(conn)
+		if err != nil {
+			return
+		}
+		switch p.kind {
+		case packetEnterTtyMode, packetIgnoreKeyRanges, packetAcceptKeyRanges:
+			send(packetAck, nil)
+		case packetDisplaySize:
+			send(packetDisplaySize, uint32s(s.cells, 1))
+		default:
+			s.packets <- p
+		}
+	}
+}
+
+func readServerPacket(conn net.Conn) (packet, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return packet{}, err
+	}
+	p := packet{
+		kind:    binary.BigEndian.Uint32(header[4:]),
+		payload: make([]byte, binary.BigEndian.Uint32(header[:4])),
+	}
+	_, err := io.ReadFull(conn, p.payload)
+	return p, err
+}
+
+// next returns the next packet sent by the client.
+func (s *fakeServer) next() packet {
+	s.t.Helper()
+	select {
+	case p := <-s.packets:
+		return p
+	case <-time.After(time.Second):
+		s.t.Fatal("the client has not sent a packet")
+		return packet{}
+	}
+}
+
+// decodeWrite returns the text of the write packet and checks the other fields.
+func decodeWrite(t *testing.T, p packet, cells int) string {
+	t.Helper()
+	if p.kind != packetWrite {
+		t.Fatalf("packet '%c', want a write packet", rune(p.kind))
+	}
+	payload := p.payload
+	field := func() uint32 {
+		v := binary.BigEndian.Uint32(payload)
+		payload = payload[4:]
+		return v
+	}
+	if flags := field(); flags != writeRegion|writeText|writeCursor|writeCharset {
+		t.Errorf("flags = %#x", flags)
+	}
+	if begin, size := field(), field(); begin != 1 || int(size) != cells {
+		t.Errorf("region = %d+%d, want 1+%d", begin, size, cells)
+	}
+	text := string(payload[:field()])
+	payload = payload[len(text):]
+	if cursor := field(); cursor != 0 {
+		t.Errorf("cursor = %d", cursor)
+	}
+	if charset := string(payload[1:]); int(payload[0]) != len(charset) || charset != "UTF-8" {
+		t.Errorf("charset = %q", payload)
+	}
+	return text
+}
+
+func newTestOutput(t *testing.T) *Output {
+	t.Helper()
+	output, err := NewOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(output.Release)
+	return output.(*Output)
+}
+
+func TestHandshakeAuthenticatesWithKey(t *testing.T) {
+	server := newFakeServer(t, "secret", 20)
+	output := newTestOutput(t)
+	if auth := server.next(); string(auth.payload[4:]) != "secret" {
+		t.Errorf("key = %q, want the trimmed content of the key file", auth.payload[4:])
+	}
+	if output.cells != 20 {
+		t.Errorf("cells = %d, want 20", output.cells)
+	}
+}
+
+func TestWrongKeyIsRejected(t *testing.T) {
+	newFakeServer(t, "other", 20)
+	if _, err := NewOutput(); err == nil || !strings.Contains(err.Error(), "error 4") {
+		t.Errorf("error = %v, want the error of the server", err)
+	}
+}
+
+func TestWriteShowsPaddedText(t *testing.T) {
+	server := newFakeServer(t, "secret", 20)
+	output := newTestOutput(t)
+	server.next()
+	if err := output.Write("Map  E1M1"); err != nil {
+		t.Fatal(err)
+	}
+	if text := decodeWrite(t, server.next(), 20); text != "Map E1M1            " {
+		t.Errorf("text = %q", text)
+	}
+}
+
+func TestPanningKeysShowWindows(t *testing.T) {
+	server := newFakeServer(t, "secret", 10)
+	output := newTestOutput(t)
+	server.next()
+	conn := <-server.conns
+	if err := output.Write("Entering the hangar"); err != nil {
+		t.Fatal(err)
+	}
+	if text := decodeWrite(t, server.next(), 10); text != "Entering  " {
+		t.Errorf("first window = %q", text)
+	}
+	key := func(code uint64) {
+		conn.Write(append(uint32s(8, packetKey), uint64s(code)...))
+	}
+	key(keyPanRight)
+	if text := decodeWrite(t, server.next(), 10); text != "the hangar" {
+		t.Errorf("second window = %q", text)
+	}
+	key(keyPanLeft)
+	if text := decodeWrite(t, server.next(), 10); text != "Entering  " {
+		t.Errorf("window after panning back = %q", text)
+	}
+}
+
+func TestReleaseLeavesTtyMode(t *testing.T) {
+	server := newFakeServer(t, "secret", 20)
+	output := newTestOutput(t)
+	server.next()
+	output.Release()
+	if p := server.next(); p.kind != packetLeaveTtyMode {
+		t.Errorf("packet '%c', want the packet leaving the tty mode", rune(p.kind))
+	}
+}
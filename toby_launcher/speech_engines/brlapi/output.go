@@ -0,0 +1,179 @@
+package brlapi
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"toby_launcher/config"
+	"toby_launcher/core/tts"
+)
+
+func init() {
+	tts.RegisterOutput("braille", NewOutput)
+}
+
+var settings = config.BrailleOutput{
+	Host:    config.DefaultBrailleHost,
+	KeyFile: config.DefaultBrailleKeyFile,
+}
+
+// Configure sets the host and the key file of the braille server.
+// It must be called before the outputs are opened.
+func Configure(cfg config.BrailleOutput) {
+	settings = cfg
+}
+
+// Output shows every phrase on a braille display through BRLTTY. A phrase longer than
+// the display is split into windows, which are panned with the panning keys of the display.
+type Output struct {
+	mu       sync.Mutex
+	client   *client
+	cells    int
+	windows  []string
+	position int
+}
+
+func NewOutput() (tts.Output, error) {
+	o := &Output{}
+	if err := o.connect(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *Output) connect() error {
+	c, err := dial(settings.Host, settings.KeyFile)
+	if err != nil {
+		return err
+	}
+	if err := c.enterTtyMode(ttyPath()); err != nil {
+		c.close()
+		return err
+	}
+	cells, err := c.displaySize()
+	if err != nil {
+		c.close()
+		return err
+	}
+	o.client = c
+	o.cells = cells
+	go o.handleKeys(c)
+	return nil
+}
+
+// ttyPath returns the path of the terminal of the launcher in the tree of the terminals of BRLTTY.
+func ttyPath() []uint32 {
+	path := make([]uint32, 0, 4)
+	for _, field := range strings.Split(os.Getenv("WINDOWPATH"), ":") {
+		if n, err := strconv.ParseUint(field, 10, 32); err == nil {
+			path = append(path, uint32(n))
+		}
+	}
+	for _, name := range []string{"WINDOWID", "CONTROLVT"} {
+		if n, err := strconv.ParseUint(os.Getenv(name), 10, 32); err == nil {
+			path = append(path, uint32(n))
+			break
+		}
+	}
+	return path
+}
+
+func (o *Output) Name() string {
+	return "braille"
+}
+
+// Write shows the first window of the text.
+func (o *Output) Write(text string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	// BRLTTY may have been restarted since the last phrase.
+	if o.client == nil || o.client.isClosed() {
+		if o.client != nil {
+			o.client.close()
+			o.client = nil
+		}
+		if err := o.connect(); err != nil {
+			return err
+		}
+	}
+	o.windows = splitWindows(text, o.cells)
+	o.position = 0
+	return o.show()
+}
+
+func (o *Output) show() error {
+	if len(o.windows) == 0 {
+		return nil
+	}
+	window := []rune(o.windows[o.position])
+	if len(window) < o.cells {
+		window = append(window, []rune(strings.Repeat(" ", o.cells-len(window)))...)
+	}
+	return o.client.write(string(window[:o.cells]), o.cells)
+}
+
+func (o *Output) handleKeys(c *client) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case key := <-c.keys:
+			o.pan(c, key&keyCodeMask)
+		}
+	}
+}
+
+func (o *Output) pan(c *client, key uint64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.client != c {
+		return
+	}
+	switch {
+	case key == keyPanLeft && o.position > 0:
+		o.position--
+	case key == keyPanRight && o.position < len(o.windows)-1:
+		o.position++
+	default:
+		return
+	}
+	o.show()
+}
+
+func (o *Output) Release() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.client != nil {
+		o.client.close()
+		o.client = nil
+	}
+}
+
+// splitWindows splits the text into windows of the given number of cells, breaking it between words if possible.
+func splitWindows(text string, cells int) []string {
+	windows := make([]string, 0, 1)
+	if cells <= 0 {
+		return windows
+	}
+	line := make([]rune, 0, cells)
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		if len(line) > 0 && len(line)+1+len(runes) > cells {
+			windows = append(windows, string(line))
+			line = line[:0]
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, runes...)
+		for len(line) > cells {
+			windows = append(windows, string(line[:cells]))
+			line = append(line[:0], line[cells:]...)
+		}
+	}
+	if len(line) > 0 {
+		windows = append(windows, string(line))
+	}
+	return windows
+}
@@ -0,0 +1,259 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"toby_launcher/config"
+	"toby_launcher/core/tts"
+)
+
+// defaultRate is the speech rate passed when neither the synthesizer nor the engine sets one.
+const defaultRate = 175
+
+// Register registers a synthesizer for every command engine defined in the configuration.
+// It must be called before the speech synthesizers are initialized.
+func Register(engines []config.CommandEngine) {
+	for _, engine := range engines {
+		engine := engine
+		tts.RegisterSynthesizer(engine.Name, func() (tts.SpeechSynthesizer, error) {
+			return NewSynthesizer(engine)
+		}, 2)
+	}
+}
+
+// Synthesizer speaks by running the command of a command engine for every phrase.
+type Synthesizer struct {
+	tts.BaseSynthesizer
+	mu         sync.Mutex
+	engine     config.CommandEngine
+	speechRate int
+	voice      string
+	cancel     context.CancelFunc
+	isSpeaking bool
+}
+
+func NewSynthesizer(engine config.CommandEngine) (tts.SpeechSynthesizer, error) {
+	if _, err := exec.LookPath(engine.Command[0]); err != nil {
+		return nil, err
+	}
+	if len(engine.Player) > 0 {
+		if _, err := exec.LookPath(engine.Player[0]); err != nil {
+			return nil, err
+		}
+	}
+	return &Synthesizer{
+		engine:     engine,
+		speechRate: engine.Rate,
+		voice:      engine.Voice,
+	}, nil
+}
+
+func (s *Synthesizer) Name() string {
+	return s.engine.Name
+}
+
+func (s *Synthesizer) CreateNew() (tts.SpeechSynthesizer, error) {
+	return NewSynthesizer(s.engine)
+}
+
+func (s *Synthesizer) Release() {
+	if err := s.Stop(); err != nil {
+		s.LogError(err)
+	}
+}
+
+func (s *Synthesizer) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop()
+	return nil
+}
+
+func (s *Synthesizer) stop() {
+	if s.cancel == nil {
+		return
+	}
+	// The speech is marked as stopped before the processes are killed, so their exit errors are not reported.
+	s.isSpeaking = false
+	s.cancel()
+	s.cancel = nil
+}
+
+func (s *Synthesizer) IsSpeaking() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isSpeaking, nil
+}
+
+func (s *Synthesizer) Speak(phrase *tts.Phrase) error {
+	if phrase.Text == "" {
+		return fmt.Errorf("no text to speak has been specified")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if phrase.Silence > 0 {
+		s.stop()
+		// The pause is a part of the speech, so stopping the speech cancels it too.
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		s.isSpeaking = true
+		phraseCopy := *phrase
+		phraseCopy.Silence = 0
+		go s.speakAfter(ctx, time.Duration(phrase.Silence)*time.Millisecond, &phraseCopy)
+		return nil
+	}
+	return s.speak(phrase)
+}
+
+// speakAfter speaks the phrase after the pause unless the speech is stopped before.
+func (s *Synthesizer) speakAfter(ctx context.Context, pause time.Duration, phrase *tts.Phrase) {
+	timer := time.NewTimer(pause)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	if err := s.speak(phrase); err != nil {
+		s.LogError(err)
+	}
+}
+
+// speak starts the command for the phrase. It is called with the mutex locked.
+func (s *Synthesizer) speak(phrase *tts.Phrase) error {
+	s.stop()
+	values := s.placeholderValues(phrase)
+	file := ""
+	if len(s.engine.Player) > 0 {
+		f, err := os.CreateTemp("", "toby_launcher_*.wav")
+		if err != nil {
+			return err
+		}
+		file = f.Name()
+		f.Close()
+		values = append(values, "$file", file)
+	}
+	replacer := strings.NewReplacer(values...)
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := s.newCommand(ctx, s.engine.Command, replacer)
+	if s.engine.Input == config.StdinInput {
+		cmd.Stdin = strings.NewReader(phrase.Text)
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		if file != "" {
+			os.Remove(file)
+		}
+		return err
+	}
+	s.cancel = cancel
+	s.isSpeaking = true
+	go s.play(ctx, cancel, cmd, replacer, file)
+	return nil
+}
+
+// play waits for the command and runs the player for the file it has written.
+func (s *Synthesizer) play(ctx context.Context, cancel context.CancelFunc, cmd *exec.Cmd, replacer *strings.Replacer, file string) {
+	defer cancel()
+	if file != "" {
+		defer os.Remove(file)
+	}
+	err := cmd.Wait()
+	if err == nil && file != "" {
+		err = s.newCommand(ctx, s.engine.Player, replacer).Run()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ctx.Err() != nil {
+		// The speech has been stopped.
+		return
+	}
+	if err != nil {
+		s.LogError(fmt.Errorf("%s: %w", s.engine.Name, err))
+	}
+	s.isSpeaking = false
+	s.cancel = nil
+}
+
+func (s *Synthesizer) newCommand(ctx context.Context, template []string, replacer *strings.Replacer) *exec.Cmd {
+	args := make([]string, 0, len(template)-1)
+	for _, arg := range template[1:] {
+		args = append(args, replacer.Replace(arg))
+	}
+	return exec.CommandContext(ctx, template[0], args...)
+}
+
+// placeholderValues returns the placeholders with their values for the phrase, falling back
+// to the synthesizer settings. The text is passed in the arguments only with the argument input.
+func (s *Synthesizer) placeholderValues(phrase *tts.Phrase) []string {
+	rate := phrase.Rate
+	if rate == 0 {
+		rate = s.speechRate
+	}
+	if rate == 0 {
+		rate = defaultRate
+	}
+	voice := phrase.Voice
+	if voice == "" {
+		voice = s.voice
+	}
+	text := phrase.Text
+	if s.engine.Input == config.StdinInput {
+		text = ""
+	}
+	return []string{"$text", text, "$rate", strconv.Itoa(rate), "$voice", voice}
+}
+
+func (s *Synthesizer) Capabilities() tts.Capabilities {
+	var caps tts.Capabilities
+	if s.engine.UsesPlaceholder("$rate") {
+		caps |= tts.RateCapability
+	}
+	if s.engine.UsesPlaceholder("$voice") {
+		caps |= tts.VoiceCapability
+	}
+	return caps
+}
+
+func (s *Synthesizer) SetSpeechRate(rate int) error {
+	if !s.engine.UsesPlaceholder("$rate") {
+		return fmt.Errorf("the command of %s does not use $rate", s.engine.Name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.speechRate = rate
+	return nil
+}
+
+func (s *Synthesizer) GetSpeechRate() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.speechRate
+}
+
+func (s *Synthesizer) SetVoice(voice string) error {
+	if !s.engine.UsesPlaceholder("$voice") {
+		return fmt.Errorf("the command of %s does not use $voice", s.engine.Name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.voice = voice
+	return nil
+}
+
+func (s *Synthesizer) GetVoice() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.voice
+}
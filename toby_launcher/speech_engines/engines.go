@@ -1,8 +1,23 @@
 package speech_engines
 
 import (
+	"toby_launcher/config"
 	_ "toby_launcher/speech_engines/NsSpeech"
+	"toby_launcher/speech_engines/brlapi"
+	"toby_launcher/speech_engines/command"
 	_ "toby_launcher/speech_engines/espeak"
+	"toby_launcher/speech_engines/festival"
 	_ "toby_launcher/speech_engines/nvda"
 	_ "toby_launcher/speech_engines/sapi"
+	_ "toby_launcher/speech_engines/speechd"
+	"toby_launcher/speech_engines/text"
 )
+
+// Configure applies the configuration of the speech engines and the outputs which depend on it.
+// It must be called before the speech synthesizers are initialized.
+func Configure(cfg *config.TtsConfig, console text.Console) {
+	festival.SetAddress(cfg.FestivalAddress)
+	command.Register(cfg.CommandEngines)
+	text.Register(cfg, console)
+	brlapi.Configure(cfg.Braille)
+}
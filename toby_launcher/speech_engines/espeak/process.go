@@ -0,0 +1,68 @@
+package espeak
+
+import (
+	"io"
+	"os/exec"
+)
+
+// process is an espeak process reading the text from its standard input.
+// It is started in advance, so the voice is loaded by the time a phrase is written,
+// and it exits when the phrase is spoken, which signals the end of the speech.
+type process struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	args  string
+	// phraseId is the id of the phrase spoken by the process.
+	phraseId int
+	done     chan struct{}
+	err      error
+	killed   bool
+}
+
+func startProcess(cmdPath string, args []string, key string) (*process, error) {
+	cmd := exec.Command(cmdPath, append(args, "--stdin")...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p := &process{
+		cmd:   cmd,
+		stdin: stdin,
+		args:  key,
+		done:  make(chan struct{}),
+	}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+func (p *process) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// speak writes the text and closes the input, so the process exits after speaking it.
+func (p *process) speak(text string) error {
+	if _, err := io.WriteString(p.stdin, text+"\n"); err != nil {
+		return err
+	}
+	return p.stdin.Close()
+}
+
+// kill stops the process. The process is marked as killed first, so its exit error is not reported.
+func (p *process) kill() error {
+	p.killed = true
+	if p.exited() {
+		return nil
+	}
+	return p.cmd.Process.Kill()
+}
@@ -3,20 +3,39 @@ package espeak
 import (
 	"fmt"
 	"os/exec"
+	"strings"
+	"sync"
 	"time"
 	"toby_launcher/core/tts"
 )
 
 func init() {
-	tts.RegisterSynthesizer(NewSynthesizer, 1)
+	tts.RegisterSynthesizer(engineName, NewSynthesizer, 1)
 }
 
+const engineName = "espeak"
+
+// spareProcesses is the number of espeak processes started in advance with the settings of the synthesizer.
+const spareProcesses = 2
+
+// Synthesizer speaks every phrase with a separate espeak process. The processes are started
+// in advance, so a phrase is spoken without waiting for espeak to start and to load the voice.
+// One long-lived process reading phrase after phrase is not used: espeak does not tell when
+// a line of its input has been spoken, so there would be no completion signal, and the only way
+// to interrupt it is to kill it, so it would be started again after every interruption anyway.
+// The benchmarks in synthesizer_test.go compare the time to the first audio of the installed espeak
+// started for the phrase and of a spare one.
 type Synthesizer struct {
 	tts.BaseSynthesizer
+	mu         sync.Mutex
 	speechRate int
-	cmd        *exec.Cmd
+	voice      string
+	pitch      int
+	volume     int
 	cmdPath    string
-	isSpeaking bool
+	current    *process
+	spares     []*process
+	onComplete func(phraseId int)
 }
 
 func NewSynthesizer() (tts.SpeechSynthesizer, error) {
@@ -33,13 +52,13 @@ func NewSynthesizer() (tts.SpeechSynthesizer, error) {
 	synth := &Synthesizer{
 		cmdPath:    cmdPath,
 		speechRate: 180,
-		isSpeaking: false,
+		spares:     make([]*process, 0, spareProcesses),
 	}
 	return synth, nil
 }
 
 func (s *Synthesizer) Name() string {
-	return "espeak"
+	return engineName
 }
 
 func (s *Synthesizer) CreateNew() (tts.SpeechSynthesizer, error) {
@@ -47,34 +66,33 @@ func (s *Synthesizer) CreateNew() (tts.SpeechSynthesizer, error) {
 }
 
 func (s *Synthesizer) Release() {
-	if err := s.Stop(); err != nil {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.stop(); err != nil {
 		s.LogError(err)
 	}
+	s.dropSpares()
 }
 
 func (s *Synthesizer) Stop() error {
-	if s.cmd == nil || s.cmd.Process == nil {
-		return fmt.Errorf("espeak process is not initialized")
-	}
-	isSpeaking, err := s.IsSpeaking()
-	if err != nil {
-		return err
-	}
-	if isSpeaking {
-		if err := s.cmd.Process.Kill(); err != nil {
-			return err
-		}
-		s.isSpeaking = false
-		s.cmd = nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stop()
+}
+
+func (s *Synthesizer) stop() error {
+	if s.current == nil {
+		return nil
 	}
-	return nil
+	p := s.current
+	s.current = nil
+	return p.kill()
 }
 
 func (s *Synthesizer) IsSpeaking() (bool, error) {
-	if s.cmd != nil && s.cmd.Process == nil {
-		return false, fmt.Errorf("espeak process is not initialized")
-	}
-	return s.isSpeaking, nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current != nil, nil
 }
 
 func (s *Synthesizer) Speak(phrase *tts.Phrase) error {
@@ -92,48 +110,233 @@ func (s *Synthesizer) Speak(phrase *tts.Phrase) error {
 		}(&phraseCopy)
 		return nil
 	}
-	isSpeaking, err := s.IsSpeaking()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.stop(); err != nil {
+		return err
+	}
+	args := s.phraseArgs(phrase)
+	key := strings.Join(args, " ")
+	p, err := s.takeProcess(args, key)
 	if err != nil {
 		return err
 	}
-	if isSpeaking {
-		if err := s.Stop(); err != nil {
+	ssml := "<speak>" + tts.SsmlText(phrase) + "</speak>"
+	if err := p.speak(ssml); err != nil {
+		// The process has died, so the phrase is spoken by a new one.
+		p.kill()
+		if p, err = startProcess(s.cmdPath, args, key); err != nil {
 			return err
 		}
+		if err := p.speak(ssml); err != nil {
+			p.kill()
+			return err
+		}
+	}
+	p.phraseId = phrase.Id
+	s.current = p
+	go s.watch(p)
+	s.fillSpares()
+	return nil
+}
+
+// takeProcess returns a spare process started with the arguments, or starts a new one.
+func (s *Synthesizer) takeProcess(args []string, key string) (*process, error) {
+	for i, p := range s.spares {
+		if p.args == key && !p.exited() {
+			s.spares = append(s.spares[:i], s.spares[i+1:]...)
+			return p, nil
+		}
+	}
+	return startProcess(s.cmdPath, args, key)
+}
+
+// fillSpares starts the spare processes with the settings of the synthesizer, replacing the dead ones.
+func (s *Synthesizer) fillSpares() {
+	args := s.phraseArgs(&tts.Phrase{})
+	key := strings.Join(args, " ")
+	alive := s.spares[:0]
+	for _, p := range s.spares {
+		if p.args == key && !p.exited() {
+			alive = append(alive, p)
+		} else {
+			p.kill()
+		}
+	}
+	s.spares = alive
+	for len(s.spares) < spareProcesses {
+		p, err := startProcess(s.cmdPath, args, key)
+		if err != nil {
+			s.LogError(err)
+			return
+		}
+		s.spares = append(s.spares, p)
+	}
+}
+
+func (s *Synthesizer) dropSpares() {
+	for _, p := range s.spares {
+		p.kill()
+	}
+	s.spares = s.spares[:0]
+}
+
+// watch waits until the process exits after speaking the phrase and reports the end of the phrase.
+func (s *Synthesizer) watch(p *process) {
+	<-p.done
+	s.mu.Lock()
+	if p.err != nil && !p.killed {
+		s.LogError(p.err)
+	}
+	if s.current == p {
+		s.current = nil
 	}
-	args := make([]string, 0, 2)
+	onComplete := s.onComplete
+	s.mu.Unlock()
+	if onComplete != nil {
+		onComplete(p.phraseId)
+	}
+}
+
+func (s *Synthesizer) SetCompletionHandler(f func(phraseId int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onComplete = f
+}
+
+// phraseArgs returns the espeak options for the settings of the phrase, falling back to the synthesizer settings.
+// The text is always written as SSML, so the spare processes serve the phrases with and without markup.
+func (s *Synthesizer) phraseArgs(phrase *tts.Phrase) []string {
+	args := make([]string, 0, 5)
+	args = append(args, "-m")
 	rate := phrase.Rate
 	if rate == 0 {
 		rate = s.speechRate
 	}
 	if rate > 0 {
-		args = append(args, fmt.Sprintf("-s%d", s.speechRate))
+		args = append(args, fmt.Sprintf("-s%d", rate))
 	}
-	args = append(args, phrase.Text)
-	s.cmd = exec.Command(s.cmdPath, args...)
-	if err := s.cmd.Start(); err != nil {
-		return err
+	voice := phrase.Voice
+	if voice == "" {
+		voice = s.voice
 	}
-	go func() {
-		if s.cmd == nil {
-			return
+	if voice != "" {
+		args = append(args, "-v", voice)
+	}
+	pitch := phrase.Pitch
+	if pitch == 0 {
+		pitch = s.pitch
+	}
+	if pitch > 0 {
+		// espeak pitch ranges from 0 to 99.
+		args = append(args, fmt.Sprintf("-p%d", min(pitch, 99)))
+	}
+	volume := phrase.Volume
+	if volume == 0 {
+		volume = s.volume
+	}
+	if volume > 0 {
+		// espeak amplitude 100 is the normal volume.
+		args = append(args, fmt.Sprintf("-a%d", volume))
+	}
+	return args
+}
+
+func (s *Synthesizer) Capabilities() tts.Capabilities {
+	return tts.RateCapability | tts.VoiceCapability | tts.PitchCapability | tts.VolumeCapability | tts.MarkupCapability
+}
+
+func (s *Synthesizer) SetVoice(voice string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.voice = voice
+	s.dropSpares()
+	return nil
+}
+
+func (s *Synthesizer) GetVoice() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.voice
+}
+
+// Voices parses the output of espeak --voices, which is a table with the columns
+// Pty, Language, Age/Gender, VoiceName, File and Other Languages.
+func (s *Synthesizer) Voices() ([]tts.Voice, error) {
+	output, err := exec.Command(s.cmdPath, "--voices").Output()
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(output), "\n")
+	voices := make([]tts.Voice, 0, len(lines))
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
 		}
-		s.isSpeaking = true
-		if err := s.cmd.Wait(); err != nil {
-			if s.isSpeaking {
-				s.LogError(err)
+		gender := ""
+		if _, g, found := strings.Cut(fields[2], "/"); found {
+			switch g {
+			case "M":
+				gender = "male"
+			case "F":
+				gender = "female"
 			}
 		}
-		s.isSpeaking = false
-	}()
+		voices = append(voices, tts.Voice{
+			Id:       fields[4],
+			Name:     strings.ReplaceAll(fields[3], "_", " "),
+			Language: fields[1],
+			Gender:   gender,
+		})
+	}
+	return voices, nil
+}
+
+func (s *Synthesizer) SetPitch(pitch int) error {
+	if pitch < 0 || pitch > tts.MaxPitch {
+		return fmt.Errorf("pitch must be from 0 to %d", tts.MaxPitch)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pitch = pitch
+	s.dropSpares()
 	return nil
 }
 
+func (s *Synthesizer) GetPitch() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pitch
+}
+
+func (s *Synthesizer) SetVolume(volume int) error {
+	if volume < 0 || volume > tts.MaxVolume {
+		return fmt.Errorf("volume must be from 0 to %d", tts.MaxVolume)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.volume = volume
+	s.dropSpares()
+	return nil
+}
+
+func (s *Synthesizer) GetVolume() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.volume
+}
+
 func (s *Synthesizer) SetSpeechRate(rate int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.speechRate = rate
+	s.dropSpares()
 	return nil
 }
 
 func (s *Synthesizer) GetSpeechRate() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.speechRate
 }
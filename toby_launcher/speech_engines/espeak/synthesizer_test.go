@@ -0,0 +1,240 @@
+package espeak
+
+import (
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+	"toby_launcher/core/tts"
+)
+
+// The test binary acts as espeak when fakeEspeakEnv is set. The fake espeak loads its voice
+// for fakeStartup, reads the phrase and connects to the address in fakeAudioEnv
+// when its audio starts, then speaks for fakeSpeech.
+const (
+	fakeEspeakEnv = "TOBY_LAUNCHER_FAKE_ESPEAK"
+	fakeAudioEnv  = "TOBY_LAUNCHER_FAKE_ESPEAK_AUDIO"
+	fakeStartup   = 30 * time.Millisecond
+	fakeSpeech    = 20 * time.Millisecond
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeEspeakEnv) != "" {
+		runFakeEspeak()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func runFakeEspeak() {
+	time.Sleep(fakeStartup)
+	if _, err := io.ReadAll(os.Stdin); err != nil {
+		os.Exit(1)
+	}
+	if conn, err := net.Dial("tcp", os.Getenv(fakeAudioEnv)); err == nil {
+		conn.Write([]byte{1})
+		conn.Close()
+	}
+	time.Sleep(fakeSpeech)
+	os.Exit(0)
+}
+
+// audioStarts returns the channel receiving a value every time a fake espeak starts its audio.
+func audioStarts(tb testing.TB) <-chan struct{} {
+	tb.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { listener.Close() })
+	tb.Setenv(fakeEspeakEnv, "1")
+	tb.Setenv(fakeAudioEnv, listener.Addr().String())
+	starts := make(chan struct{}, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			io.ReadAll(conn)
+			conn.Close()
+			starts <- struct{}{}
+		}
+	}()
+	return starts
+}
+
+func newFakeSynthesizer(tb testing.TB) *Synthesizer {
+	tb.Helper()
+	synth := &Synthesizer{
+		cmdPath:    os.Args[0],
+		speechRate: 180,
+		spares:     make([]*process, 0, spareProcesses),
+	}
+	tb.Cleanup(synth.Release)
+	return synth
+}
+
+func waitAudio(tb testing.TB, starts <-chan struct{}) {
+	tb.Helper()
+	select {
+	case <-starts:
+	case <-time.After(5 * time.Second):
+		tb.Fatal("the audio has not started")
+	}
+}
+
+func TestSpeakReportsCompletion(t *testing.T) {
+	starts := audioStarts(t)
+	synth := newFakeSynthesizer(t)
+	completed := make(chan int, 1)
+	synth.SetCompletionHandler(func(phraseId int) { completed <- phraseId })
+	if err := synth.Speak(&tts.Phrase{Id: 5, Text: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if speaking, _ := synth.IsSpeaking(); !speaking {
+		t.Error("the synthesizer is not speaking")
+	}
+	waitAudio(t, starts)
+	select {
+	case id := <-completed:
+		if id != 5 {
+			t.Errorf("completed phrase %d, want 5", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the end of the phrase was not reported")
+	}
+	if speaking, _ := synth.IsSpeaking(); speaking {
+		t.Error("the synthesizer is speaking after the end of the phrase")
+	}
+}
+
+func TestStopKillsProcess(t *testing.T) {
+	audioStarts(t)
+	synth := newFakeSynthesizer(t)
+	if err := synth.Speak(&tts.Phrase{Id: 1, Text: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	synth.mu.Lock()
+	p := synth.current
+	synth.mu.Unlock()
+	if err := synth.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if speaking, _ := synth.IsSpeaking(); speaking {
+		t.Error("the synthesizer is speaking after the stop")
+	}
+	select {
+	case <-p.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stopped process is still running")
+	}
+}
+
+func TestSparesFollowSettings(t *testing.T) {
+	audioStarts(t)
+	synth := newFakeSynthesizer(t)
+	if err := synth.Speak(&tts.Phrase{Id: 1, Text: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	synth.SetSpeechRate(300)
+	synth.mu.Lock()
+	spares := len(synth.spares)
+	synth.mu.Unlock()
+	if spares != 0 {
+		t.Errorf("%d spare processes with the old rate are kept", spares)
+	}
+	if args := synth.phraseArgs(&tts.Phrase{}); args[1] != "-s300" {
+		t.Errorf("args = %q", args)
+	}
+}
+
+// spareWarmup is the time a spare espeak has to load the voice before the phrase is written to it.
+// In a game the spare loads it while the previous phrase is spoken, which takes longer.
+const spareWarmup = 500 * time.Millisecond
+
+// benchmarkProcess is espeak started with the arguments of the synthesizer, which writes the audio
+// to its standard output instead of playing it, so the first audio can be seen.
+type benchmarkProcess struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+}
+
+func startBenchmarkProcess(b *testing.B, cmdPath string, args []string) *benchmarkProcess {
+	b.Helper()
+	cmd := exec.Command(cmdPath, append(args, "--stdin", "--stdout")...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		b.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		b.Fatal(err)
+	}
+	return &benchmarkProcess{cmd: cmd, stdin: stdin, stdout: stdout}
+}
+
+// speak writes the phrase as the synthesizer does and waits for the first samples after the WAV header.
+func (p *benchmarkProcess) speak(b *testing.B, text string) {
+	b.Helper()
+	if _, err := io.WriteString(p.stdin, "<speak>"+text+"</speak>\n"); err != nil {
+		b.Fatal(err)
+	}
+	p.stdin.Close()
+	const wavHeaderSize = 44
+	if _, err := io.ReadFull(p.stdout, make([]byte, wavHeaderSize+1)); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func (p *benchmarkProcess) wait() {
+	io.Copy(io.Discard, p.stdout)
+	p.cmd.Wait()
+}
+
+// realEspeak returns the synthesizer of the installed espeak, skipping the benchmark without it.
+func realEspeak(b *testing.B) *Synthesizer {
+	b.Helper()
+	synth, err := NewSynthesizer()
+	if err != nil {
+		b.Skipf("espeak is not installed: %v", err)
+	}
+	return synth.(*Synthesizer)
+}
+
+// BenchmarkFirstAudioNewProcess measures the time to the first audio of the installed espeak
+// when it is started for the phrase, as the engine did before the spare processes.
+func BenchmarkFirstAudioNewProcess(b *testing.B) {
+	synth := realEspeak(b)
+	args := synth.phraseArgs(&tts.Phrase{})
+	for i := 0; i < b.N; i++ {
+		p := startBenchmarkProcess(b, synth.cmdPath, args)
+		p.speak(b, "Entering the hangar")
+		b.StopTimer()
+		p.wait()
+		b.StartTimer()
+	}
+}
+
+// BenchmarkFirstAudioSpareProcess measures the time to the first audio of the installed espeak
+// when the phrase is written to a spare process, which has loaded the voice in advance.
+func BenchmarkFirstAudioSpareProcess(b *testing.B) {
+	synth := realEspeak(b)
+	args := synth.phraseArgs(&tts.Phrase{})
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		p := startBenchmarkProcess(b, synth.cmdPath, args)
+		time.Sleep(spareWarmup)
+		b.StartTimer()
+		p.speak(b, "Entering the hangar")
+		b.StopTimer()
+		p.wait()
+		b.StartTimer()
+	}
+}
@@ -0,0 +1,455 @@
+package festival
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"toby_launcher/config"
+	"toby_launcher/core/tts"
+)
+
+func init() {
+	tts.RegisterSynthesizer(engineName, NewSynthesizer, 2)
+}
+
+const engineName = "festival"
+
+const (
+	dialTimeout  = 500 * time.Millisecond
+	replyTimeout = 5 * time.Second
+	// sayTimeout limits the synthesis of a phrase, which is longer than the evaluation of the settings.
+	sayTimeout = 30 * time.Second
+	// normalWpm is the speech rate of Festival with Duration_Stretch 1.
+	normalWpm = 175
+	// dataEnd ends the data of the LP and WV replies of the server.
+	dataEnd = "ft_StUfF_key"
+)
+
+var address = config.DefaultFestivalAddress
+
+// voiceNamePattern matches the names of the voices, which are selected by calling the function voice_<name>.
+var voiceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// SetAddress sets the address of the Festival server the synthesizers connect to.
+// It must be called before the speech synthesizers are initialized.
+func SetAddress(addr string) {
+	address = addr
+}
+
+// connection is a connection to the Festival server, which evaluates the expressions one after another.
+type connection struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dial(addr string) (*connection, error) {
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &connection{conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// eval sends the expression and waits until the server has evaluated it.
+// It returns the data of the LP replies, which are the printed Lisp results.
+func (c *connection) eval(expr string) (string, error) {
+	return c.evalWithin(expr, replyTimeout)
+}
+
+func (c *connection) evalWithin(expr string, timeout time.Duration) (string, error) {
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	defer c.conn.SetDeadline(time.Time{})
+	if err := c.send(expr); err != nil {
+		return "", err
+	}
+	return c.readReply()
+}
+
+func (c *connection) send(expr string) error {
+	_, err := io.WriteString(c.conn, expr+"\n")
+	return err
+}
+
+func (c *connection) close() {
+	c.conn.Close()
+}
+
+// readReply reads the replies of the server up to the final OK or ER.
+func (c *connection) readReply() (string, error) {
+	reader := c.reader
+	var result strings.Builder
+	for {
+		status, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		switch strings.TrimSpace(status) {
+		case "OK":
+			return result.String(), nil
+		case "ER":
+			return "", fmt.Errorf("festival failed to evaluate the command")
+		case "LP", "WV":
+			data, err := readData(reader)
+			if err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(status) == "LP" {
+				result.Write(data)
+			}
+		default:
+			return "", fmt.Errorf("unexpected festival reply \"%s\"", strings.TrimSpace(status))
+		}
+	}
+}
+
+func readData(reader *bufio.Reader) ([]byte, error) {
+	var data []byte
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b)
+		if bytes.HasSuffix(data, []byte(dataEnd)) {
+			return data[:len(data)-len(dataEnd)], nil
+		}
+	}
+}
+
+// quote returns the text as a Scheme string.
+func quote(text string) string {
+	text = strings.ReplaceAll(text, "\\", "\\\\")
+	text = strings.ReplaceAll(text, "\"", "\\\"")
+	return "\"" + text + "\""
+}
+
+// Synthesizer speaks through a Festival server started with "festival --server".
+// The server speaks in the async audio mode, as festival.el does: it replies to SayText once the phrase
+// is synthesized and plays it in the background, so the (audio_mode 'shutup) sent over the same connection
+// stops it. The reply tells the duration of the phrase, after which the phrase is finished.
+// The expressions are evaluated by the worker of the connection in the order they are requested,
+// so the synthesizer does not wait for the server.
+type Synthesizer struct {
+	tts.BaseSynthesizer
+	mu         sync.Mutex
+	address    string
+	worker     *worker
+	speechRate int
+	voice      string
+	// serial identifies the current phrase, it is increased when the speech is stopped.
+	serial     int
+	isSpeaking bool
+	timer      *time.Timer
+}
+
+// worker evaluates the requests on its connection one after another in a separate goroutine.
+// The requests are guarded by the mutex of the synthesizer.
+type worker struct {
+	conn     *connection
+	requests []func(w *worker) error
+	wake     chan struct{}
+	closed   bool
+	// sentRate and sentVoice are the settings sent over the connection, used by the goroutine only.
+	sentRate  int
+	sentVoice string
+}
+
+func NewSynthesizer() (tts.SpeechSynthesizer, error) {
+	synth := &Synthesizer{address: address}
+	if err := synth.connect(); err != nil {
+		return nil, err
+	}
+	return synth, nil
+}
+
+// connect connects to the server and starts the worker. It is called with the mutex locked.
+func (s *Synthesizer) connect() error {
+	conn, err := dial(s.address)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.eval("(audio_mode 'async)"); err != nil {
+		conn.close()
+		return err
+	}
+	w := &worker{conn: conn, wake: make(chan struct{}, 1)}
+	s.worker = w
+	go s.run(w)
+	return nil
+}
+
+// request queues the request to the worker. It is called with the mutex locked.
+func (s *Synthesizer) request(f func(w *worker) error) {
+	s.worker.requests = append(s.worker.requests, f)
+	s.wakeWorker()
+}
+
+func (s *Synthesizer) wakeWorker() {
+	select {
+	case s.worker.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run evaluates the requests until the worker is closed and has no requests left, or until the connection fails.
+func (s *Synthesizer) run(w *worker) {
+	defer w.conn.close()
+	for {
+		s.mu.Lock()
+		if len(w.requests) == 0 {
+			closed := w.closed
+			s.mu.Unlock()
+			if closed {
+				return
+			}
+			<-w.wake
+			continue
+		}
+		f := w.requests[0]
+		w.requests = w.requests[1:]
+		s.mu.Unlock()
+		if err := f(w); err != nil {
+			s.LogError(err)
+			s.mu.Lock()
+			if s.worker == w {
+				// The next phrase connects again.
+				s.worker = nil
+				s.finish(s.serial)
+			}
+			s.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (s *Synthesizer) Name() string {
+	return engineName
+}
+
+func (s *Synthesizer) CreateNew() (tts.SpeechSynthesizer, error) {
+	return NewSynthesizer()
+}
+
+// Release stops the speech and closes the connection once the worker has sent the remaining requests.
+func (s *Synthesizer) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop()
+	if s.worker != nil {
+		s.worker.closed = true
+		s.wakeWorker()
+		s.worker = nil
+	}
+}
+
+func (s *Synthesizer) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop()
+	return nil
+}
+
+// stop stops the current phrase. It is called with the mutex locked.
+func (s *Synthesizer) stop() {
+	if !s.isSpeaking {
+		return
+	}
+	s.finish(s.serial)
+	s.serial++
+	if s.worker != nil {
+		s.request(func(w *worker) error {
+			_, err := w.conn.eval("(audio_mode 'shutup)")
+			return err
+		})
+	}
+}
+
+// finish marks the phrase as spoken if it is the current one. It is called with the mutex locked.
+func (s *Synthesizer) finish(serial int) {
+	if serial != s.serial {
+		return
+	}
+	s.isSpeaking = false
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+func (s *Synthesizer) IsSpeaking() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isSpeaking, nil
+}
+
+func (s *Synthesizer) Speak(phrase *tts.Phrase) error {
+	if phrase.Text == "" {
+		return fmt.Errorf("no text to speak has been specified")
+	}
+	if phrase.Silence > 0 {
+		phraseCopy := *phrase
+		go func(phrase *tts.Phrase) {
+			time.Sleep(time.Duration(phrase.Silence) * time.Millisecond)
+			phrase.Silence = 0
+			if err := s.Speak(phrase); err != nil {
+				s.LogError(err)
+			}
+		}(&phraseCopy)
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop()
+	rate, voice, err := s.phraseSettings(phrase)
+	if err != nil {
+		return err
+	}
+	if s.worker == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	s.serial++
+	s.isSpeaking = true
+	serial := s.serial
+	text := phrase.Text
+	s.request(func(w *worker) error {
+		return s.say(w, serial, text, rate, voice)
+	})
+	return nil
+}
+
+// say sends the settings and the text of the phrase unless it has been stopped,
+// and finishes the phrase when its audio has been played.
+func (s *Synthesizer) say(w *worker, serial int, text string, rate int, voice string) error {
+	s.mu.Lock()
+	isStopped := serial != s.serial
+	s.mu.Unlock()
+	if isStopped {
+		return nil
+	}
+	if err := w.applySettings(rate, voice); err != nil {
+		return err
+	}
+	// SayText returns the utterance, the end of its last segment is the duration in seconds.
+	expr := fmt.Sprintf("(let ((utt (SayText %s))) (item.feat (utt.relation.last utt 'Segment) 'end))", quote(text))
+	result, err := w.conn.evalWithin(expr, sayTimeout)
+	if err != nil {
+		return err
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(result), 64)
+	if err != nil {
+		return fmt.Errorf("unexpected festival duration \"%s\"", strings.TrimSpace(result))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if serial == s.serial && s.isSpeaking {
+		s.timer = time.AfterFunc(time.Duration(seconds*float64(time.Second)), func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.finish(serial)
+		})
+	}
+	return nil
+}
+
+// phraseSettings returns the settings of the phrase, falling back to the synthesizer settings.
+func (s *Synthesizer) phraseSettings(phrase *tts.Phrase) (int, string, error) {
+	rate := phrase.Rate
+	if rate == 0 {
+		rate = s.speechRate
+	}
+	if rate == 0 {
+		rate = normalWpm
+	}
+	voice := phrase.Voice
+	if voice == "" {
+		voice = s.voice
+	}
+	if voice != "" && !voiceNamePattern.MatchString(voice) {
+		return 0, "", fmt.Errorf("invalid festival voice name \"%s\"", voice)
+	}
+	return rate, voice, nil
+}
+
+// applySettings sends the settings which differ from the ones sent over the connection.
+func (w *worker) applySettings(rate int, voice string) error {
+	if rate != w.sentRate {
+		stretch := strconv.FormatFloat(float64(normalWpm)/float64(rate), 'f', 3, 64)
+		if _, err := w.conn.eval(fmt.Sprintf("(Parameter.set 'Duration_Stretch %s)", stretch)); err != nil {
+			return err
+		}
+		w.sentRate = rate
+	}
+	if voice != "" && voice != w.sentVoice {
+		if _, err := w.conn.eval(fmt.Sprintf("(voice_%s)", voice)); err != nil {
+			return err
+		}
+		w.sentVoice = voice
+	}
+	return nil
+}
+
+func (s *Synthesizer) Capabilities() tts.Capabilities {
+	return tts.RateCapability | tts.VoiceCapability
+}
+
+func (s *Synthesizer) SetSpeechRate(rate int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.speechRate = rate
+	return nil
+}
+
+func (s *Synthesizer) GetSpeechRate() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.speechRate
+}
+
+func (s *Synthesizer) SetVoice(voice string) error {
+	if voice != "" && !voiceNamePattern.MatchString(voice) {
+		return fmt.Errorf("invalid festival voice name \"%s\"", voice)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.voice = voice
+	return nil
+}
+
+func (s *Synthesizer) GetVoice() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.voice
+}
+
+// Voices lists the voices known to the server over a separate connection, so the speech is not interrupted.
+// The languages are not listed, as Festival has to load a voice to describe it.
+func (s *Synthesizer) Voices() ([]tts.Voice, error) {
+	conn, err := dial(s.address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.close()
+	result, err := conn.eval("(voice.list)")
+	if err != nil {
+		return nil, err
+	}
+	names := strings.Fields(strings.Trim(strings.TrimSpace(result), "()"))
+	voices := make([]tts.Voice, 0, len(names))
+	for _, name := range names {
+		voices = append(voices, tts.Voice{
+			Id:       name,
+			Name:     strings.ReplaceAll(name, "_", " "),
+			Language: "unknown",
+		})
+	}
+	return voices, nil
+}
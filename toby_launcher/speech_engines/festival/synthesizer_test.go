@@ -0,0 +1,291 @@
+package festival
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"toby_launcher/core/tts"
+)
+
+// stubServer is a Festival server in the async audio mode evaluating every expression successfully.
+// SayText is answered with the duration of the phrase as soon as it is received,
+// and the phrase is played until the duration passes or until the server is told to shut up.
+type stubServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	// exprs are the expressions received, prefixed with the number of the connection.
+	exprs    []string
+	conns    int
+	duration string
+}
+
+func newStubServer(t *testing.T, duration string) *stubServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &stubServer{listener: listener, duration: duration}
+	t.Cleanup(func() { listener.Close() })
+	previous := address
+	SetAddress(listener.Addr().String())
+	t.Cleanup(func() { SetAddress(previous) })
+	go s.serve()
+	return s
+}
+
+func (s *stubServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		id := s.conns
+		s.mu.Unlock()
+		go s.handle(conn, id)
+	}
+}
+
+func (s *stubServer) handle(conn net.Conn, id int) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		expr := strings.TrimSpace(line)
+		s.mu.Lock()
+		s.exprs = append(s.exprs, fmt.Sprintf("%d %s", id, expr))
+		duration := s.duration
+		s.mu.Unlock()
+		switch {
+		case strings.HasPrefix(expr, "(let ((utt (SayText "):
+			conn.Write([]byte("LP\n" + duration + "\nft_StUfF_keyOK\n"))
+		case expr == "(voice.list)":
+			conn.Write([]byte("LP\n(kal_diphone rab_diphone)\nft_StUfF_keyOK\n"))
+		case expr == "(voice_missing)":
+			conn.Write([]byte("ER\n"))
+		default:
+			conn.Write([]byte("OK\n"))
+		}
+	}
+}
+
+func (s *stubServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.exprs...)
+}
+
+// waitFor waits until the server has received the expression, prefixed with the number of the connection.
+func (s *stubServer) waitFor(t *testing.T, expr string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, received := range s.received() {
+			if received == expr {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("the server has not received %s, only %q", expr, s.received())
+}
+
+func sayExpr(text string) string {
+	return fmt.Sprintf("(let ((utt (SayText %s))) (item.feat (utt.relation.last utt 'Segment) 'end))", quote(text))
+}
+
+func newTestSynthesizer(t *testing.T) *Synthesizer {
+	t.Helper()
+	synth, err := NewSynthesizer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(synth.Release)
+	return synth.(*Synthesizer)
+}
+
+func waitSilent(t *testing.T, synth *Synthesizer, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if speaking, _ := synth.IsSpeaking(); !speaking {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("the synthesizer is still speaking")
+}
+
+func TestSpeak(t *testing.T) {
+	server := newStubServer(t, "0.2")
+	synth := newTestSynthesizer(t)
+	synth.SetSpeechRate(350)
+	synth.SetVoice("kal_diphone")
+	start := time.Now()
+	if err := synth.Speak(&tts.Phrase{Id: 1, Text: `say "hi" \ bye`}); err != nil {
+		t.Fatal(err)
+	}
+	say := "1 " + sayExpr(`say "hi" \ bye`)
+	server.waitFor(t, say)
+	if speaking, _ := synth.IsSpeaking(); !speaking {
+		t.Error("the synthesizer is not speaking during the phrase")
+	}
+	waitSilent(t, synth, time.Second)
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("the phrase of 0.2 s finished after %s", elapsed)
+	}
+	want := []string{
+		"1 (audio_mode 'async)",
+		"1 (Parameter.set 'Duration_Stretch 0.500)",
+		"1 (voice_kal_diphone)",
+		say,
+	}
+	if got := server.received(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expressions = %q, want %q", got, want)
+	}
+}
+
+func TestSettingsAreSentOnce(t *testing.T) {
+	server := newStubServer(t, "0")
+	synth := newTestSynthesizer(t)
+	for id := 1; id <= 2; id++ {
+		if err := synth.Speak(&tts.Phrase{Id: id, Text: "text"}); err != nil {
+			t.Fatal(err)
+		}
+		waitSilent(t, synth, time.Second)
+	}
+	count := 0
+	for _, expr := range server.received() {
+		if strings.HasPrefix(expr, "1 (Parameter.set 'Duration_Stretch") {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("the rate was sent %d times in %q", count, server.received())
+	}
+}
+
+func TestStopShutsUpOnSameConnection(t *testing.T) {
+	server := newStubServer(t, "10")
+	synth := newTestSynthesizer(t)
+	if err := synth.Speak(&tts.Phrase{Id: 1, Text: "long text"}); err != nil {
+		t.Fatal(err)
+	}
+	server.waitFor(t, "1 "+sayExpr("long text"))
+	if err := synth.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if speaking, _ := synth.IsSpeaking(); speaking {
+		t.Error("the synthesizer is speaking after the stop")
+	}
+	server.waitFor(t, "1 (audio_mode 'shutup)")
+	// The next phrase stops the current one the same way.
+	if err := synth.Speak(&tts.Phrase{Id: 2, Text: "next"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := synth.Speak(&tts.Phrase{Id: 3, Text: "last"}); err != nil {
+		t.Fatal(err)
+	}
+	server.waitFor(t, "1 "+sayExpr("last"))
+	exprs := server.received()
+	want := []string{"1 (audio_mode 'shutup)", "1 " + sayExpr("last")}
+	if got := exprs[len(exprs)-2:]; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("last expressions = %q, want %q", got, want)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.conns != 1 {
+		t.Errorf("the synthesizer connected %d times", server.conns)
+	}
+}
+
+func TestVoices(t *testing.T) {
+	newStubServer(t, "0")
+	synth := newTestSynthesizer(t)
+	voices, err := synth.Voices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(voices) != 2 || voices[0].Id != "kal_diphone" || voices[1].Name != "rab diphone" {
+		t.Errorf("voices = %+v", voices)
+	}
+}
+
+func TestEvalError(t *testing.T) {
+	newStubServer(t, "0")
+	conn, err := dial(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.close()
+	if _, err := conn.eval("(voice_missing)"); err == nil {
+		t.Error("the ER reply was not reported")
+	}
+}
+
+// TestStopRealServer checks the stop against festival --server, if Festival is installed.
+// The server needs an audio device to play the phrase.
+func TestStopRealServer(t *testing.T) {
+	festival, err := exec.LookPath("festival")
+	if err != nil {
+		t.Skip("festival is not installed")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	script := filepath.Join(t.TempDir(), "server.scm")
+	if err := os.WriteFile(script, []byte("(set! server_port "+strconv.Itoa(port)+")\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(festival, "--server", script)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	previous := address
+	SetAddress(net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	t.Cleanup(func() { SetAddress(previous) })
+	var synth tts.SpeechSynthesizer
+	for i := 0; i < 50 && synth == nil; i++ {
+		time.Sleep(100 * time.Millisecond)
+		synth, _ = NewSynthesizer()
+	}
+	if synth == nil {
+		t.Fatal("failed to connect to festival --server")
+	}
+	t.Cleanup(synth.Release)
+	long := strings.Repeat("This phrase is long enough to be stopped while it is played. ", 5)
+	if err := synth.Speak(&tts.Phrase{Id: 1, Text: long}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	if speaking, _ := synth.IsSpeaking(); !speaking {
+		t.Fatal("the long phrase is not spoken")
+	}
+	if err := synth.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := synth.Speak(&tts.Phrase{Id: 2, Text: "Stopped."}); err != nil {
+		t.Fatal(err)
+	}
+	waitSilent(t, synth.(*Synthesizer), 10*time.Second)
+}
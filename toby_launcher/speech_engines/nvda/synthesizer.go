@@ -9,9 +9,11 @@ import (
 )
 
 func init() {
-	tts.RegisterSynthesizer(NewSynthesizer, 1)
+	tts.RegisterSynthesizer(engineName, NewSynthesizer, 1)
 }
 
+const engineName = "nvda"
+
 type Synthesizer struct {
 	tts.BaseSynthesizer
 	synth      *nvdaSynthesizer
@@ -30,7 +32,7 @@ func NewSynthesizer() (tts.SpeechSynthesizer, error) {
 }
 
 func (s *Synthesizer) Name() string {
-	return "nvda"
+	return engineName
 }
 
 func (s *Synthesizer) CreateNew() (tts.SpeechSynthesizer, error) {
@@ -99,8 +101,8 @@ func (s *Synthesizer) GetSpeechRate() int {
 	return 0
 }
 
-func (s *Synthesizer) SupportsChangingSpeechRate() bool {
-	return false
+func (s *Synthesizer) Capabilities() tts.Capabilities {
+	return 0
 }
 
 func (s *Synthesizer) IsSpeaking() (bool, error) {
@@ -4,18 +4,22 @@ package sapi
 
 import (
 	"fmt"
+	"math"
 	"toby_launcher/core/tts"
 )
 
 func init() {
-	tts.RegisterSynthesizer(NewSynthesizer, 0)
+	tts.RegisterSynthesizer(engineName, NewSynthesizer, 0)
 }
 
+const engineName = "sapi (unstable)"
+
 type Synthesizer struct {
 	tts.BaseSynthesizer
 	speechRate int
 	synth      *sapiSynthesizer
 	isSpeaking bool
+	onComplete func(phraseId int)
 }
 
 func NewSynthesizer() (tts.SpeechSynthesizer, error) {
@@ -37,7 +41,7 @@ func NewSynthesizer() (tts.SpeechSynthesizer, error) {
 }
 
 func (s *Synthesizer) Name() string {
-	return "sapi (unstable)"
+	return engineName
 }
 
 func (s *Synthesizer) CreateNew() (tts.SpeechSynthesizer, error) {
@@ -97,50 +101,76 @@ func (s *Synthesizer) Speak(phrase *tts.Phrase) error {
 		return err
 	}
 	s.isSpeaking = true
+	onComplete := s.onComplete
 	go func() {
 		if err := s.synth.wait(); err != nil {
 			s.LogError(err)
 		}
 		s.isSpeaking = false
+		if onComplete != nil {
+			onComplete(phrase.Id)
+		}
 	}()
 	return nil
 }
 
+func (s *Synthesizer) SetCompletionHandler(f func(phraseId int)) {
+	s.onComplete = f
+}
+
+// handlePhrase returns the SSML of the phrase. The rate of the voice is set by SetSpeechRate,
+// so only the rate of a phrase differing from it is marked up, relative to the rate of the voice.
 func (s *Synthesizer) handlePhrase(p *tts.Phrase) string {
-	rate := p.Rate
-	if rate == 0 {
-		rate = s.speechRate
-	}
-	prosodyRate := s.rateToSapiRate(rate)
 	ssml := `<?xml version="1.0" encoding="UTF-8"?><speak version="1.0" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="en-US">`
 	if p.Silence > 0 {
 		ssml += fmt.Sprintf(`<break time="%dms"/>`, p.Silence)
 	}
-	if prosodyRate != 0 {
-		ssml += fmt.Sprintf(`<prosody rate="%s">%s</prosody>`, string(prosodyRate), p.Text)
+	if percent := s.relativeRate(p.Rate); percent != 0 {
+		ssml += fmt.Sprintf(`<prosody rate="%+d%%">%s</prosody>`, percent, tts.SsmlText(p))
 	} else {
-		ssml += p.Text
+		ssml += tts.SsmlText(p)
 	}
 	ssml += `</speak>`
 	return ssml
 }
 
+// rateScale maps words per minute to the SAPI rates from -10 to 10. The rate 0 is about 180 words
+// per minute, the rate -10 is three times slower and the rate 10 is three times faster.
+var rateScale = tts.RateScale{
+	MinWpm:     60,
+	NormalWpm:  180,
+	MaxWpm:     540,
+	MinRate:    -10,
+	NormalRate: 0,
+	MaxRate:    10,
+}
+
 func (s *Synthesizer) rateToSapiRate(rate int) int {
 	if rate <= 0 {
 		return 0
 	}
-	// Map rate (0-600) to SAPI's -10 to +10
-	sapiRate := (float64(rate) - 250) / 25
-	if sapiRate < -10 {
-		sapiRate = -10
-	} else if sapiRate > 10 {
-		sapiRate = 10
+	return int(math.Round(rateScale.FromWpm(rate)))
+}
+
+// relativeRate returns the change of the rate in percent from the rate of the voice,
+// 0 if the rate is not set.
+func (s *Synthesizer) relativeRate(rate int) int {
+	if rate <= 0 || s.speechRate <= 0 {
+		return 0
 	}
-	return int(sapiRate)
+	return int(math.Round(float64(rate)*100/float64(s.speechRate))) - 100
 }
 
 func (s *Synthesizer) sapiRateToRate(rate int) int {
-	return (rate * 25) + 250
+	return rateScale.ToWpm(float64(rate))
+}
+
+func (s *Synthesizer) Capabilities() tts.Capabilities {
+	return tts.RateCapability | tts.MarkupCapability
+}
+
+func (s *Synthesizer) RateScale() tts.RateScale {
+	return rateScale
 }
 
 func (s *Synthesizer) SetSpeechRate(rate int) error {
@@ -0,0 +1,197 @@
+//go:build linux
+
+package speechd
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dialTimeout  = 2 * time.Second
+	replyTimeout = 5 * time.Second
+)
+
+// Codes of the SSIP replies and events.
+const (
+	codeReceivingData = 230
+	codeMessageQueued = 225
+	codeEventEnd      = 702
+	codeEventCanceled = 703
+)
+
+// socketPath returns the path of the Unix socket of Speech Dispatcher.
+// SPEECHD_ADDRESS takes precedence, as in the Speech Dispatcher client library.
+func socketPath() (string, error) {
+	if address := os.Getenv("SPEECHD_ADDRESS"); address != "" {
+		method, path, _ := strings.Cut(address, ":")
+		if method != "unix_socket" {
+			return "", fmt.Errorf("unsupported speech dispatcher address \"%s\"", address)
+		}
+		if path != "" {
+			return path, nil
+		}
+	}
+	candidates := make([]string, 0, 2)
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		candidates = append(candidates, filepath.Join(dir, "speech-dispatcher", "speechd.sock"))
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		candidates = append(candidates, filepath.Join(dir, "speech-dispatcher", "speechd.sock"))
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("speech dispatcher socket not found")
+}
+
+// reply is a reply of Speech Dispatcher: the lines before the final one and the text of the final line.
+type reply struct {
+	code  int
+	lines []string
+	text  string
+}
+
+// client talks SSIP to Speech Dispatcher. Commands are sent one at a time and wait for their reply,
+// while events are passed to the callback from the reading goroutine.
+type client struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	replies chan reply
+	done    chan struct{}
+	onEvent func(code, msgId int)
+}
+
+func dial(path string, onEvent func(code, msgId int)) (*client, error) {
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &client{
+		conn:    conn,
+		replies: make(chan reply),
+		done:    make(chan struct{}),
+		onEvent: onEvent,
+	}
+	go c.read()
+	return c, nil
+}
+
+func (c *client) read() {
+	defer close(c.done)
+	reader := bufio.NewReader(c.conn)
+	lines := make([]string, 0, 4)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) < 4 {
+			continue
+		}
+		code, err := strconv.Atoi(line[:3])
+		if err != nil {
+			continue
+		}
+		if line[3] == '-' {
+			lines = append(lines, line[4:])
+			continue
+		}
+		r := reply{code: code, lines: lines, text: line[4:]}
+		lines = make([]string, 0, 4)
+		if code >= 700 && code < 800 {
+			// Events carry the message id and the client id before the event name.
+			if len(r.lines) > 0 {
+				if msgId, err := strconv.Atoi(r.lines[0]); err == nil {
+					c.onEvent(code, msgId)
+				}
+			}
+			continue
+		}
+		select {
+		case c.replies <- r:
+		case <-time.After(replyTimeout):
+		}
+	}
+}
+
+func (c *client) isClosed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// send writes the data and waits for the reply. Codes from 300 up are errors.
+func (c *client) send(data string) (reply, error) {
+	if _, err := c.conn.Write([]byte(data)); err != nil {
+		return reply{}, err
+	}
+	select {
+	case r := <-c.replies:
+		if r.code >= 300 {
+			return r, fmt.Errorf("speech dispatcher error %d: %s", r.code, r.text)
+		}
+		return r, nil
+	case <-c.done:
+		return reply{}, fmt.Errorf("speech dispatcher closed the connection")
+	case <-time.After(replyTimeout):
+		return reply{}, fmt.Errorf("speech dispatcher does not respond")
+	}
+}
+
+func (c *client) command(cmd string) (reply, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.send(cmd + "\r\n")
+}
+
+// speak sends the text and returns the id of the queued message.
+func (c *client) speak(text string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, err := c.send("SPEAK\r\n")
+	if err != nil {
+		return 0, err
+	}
+	if r.code != codeReceivingData {
+		return 0, fmt.Errorf("unexpected speech dispatcher reply %d: %s", r.code, r.text)
+	}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		// A line with a single dot ends the data, so leading dots are doubled.
+		if strings.HasPrefix(line, ".") {
+			lines[i] = "." + line
+		}
+	}
+	r, err = c.send(strings.Join(lines, "\r\n") + "\r\n.\r\n")
+	if err != nil {
+		return 0, err
+	}
+	if r.code != codeMessageQueued || len(r.lines) == 0 {
+		return 0, fmt.Errorf("unexpected speech dispatcher reply %d: %s", r.code, r.text)
+	}
+	return strconv.Atoi(r.lines[0])
+}
+
+func (c *client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.isClosed() {
+		c.conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+		c.conn.Write([]byte("QUIT\r\n"))
+	}
+	c.conn.Close()
+}
@@ -0,0 +1,228 @@
+//go:build linux
+
+package speechd
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"toby_launcher/core/tts"
+)
+
+// fakeServer is a Speech Dispatcher speaking enough SSIP for the synthesizer.
+// Messages end by themselves only if autoEnd is set, otherwise they are spoken until canceled.
+type fakeServer struct {
+	t        *testing.T
+	listener net.Listener
+	mu       sync.Mutex
+	commands []string
+	data     []string
+	autoEnd  bool
+	lastId   int
+}
+
+func newFakeServer(t *testing.T, autoEnd bool) *fakeServer {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "speechd.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SPEECHD_ADDRESS", "unix_socket:"+path)
+	s := &fakeServer{t: t, listener: listener, autoEnd: autoEnd}
+	t.Cleanup(func() { listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	write := func(lines ...string) {
+		conn.Write([]byte(strings.Join(lines, "\r\n") + "\r\n"))
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		s.mu.Lock()
+		s.commands = append(s.commands, cmd)
+		s.mu.Unlock()
+		switch {
+		case strings.HasPrefix(cmd, "SET "):
+			write("208 OK SET")
+		case cmd == "SPEAK":
+			write("230 OK RECEIVING DATA")
+			lines := make([]string, 0, 1)
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				dataLine = strings.TrimRight(dataLine, "\r\n")
+				if dataLine == "." {
+					break
+				}
+				lines = append(lines, dataLine)
+			}
+			s.mu.Lock()
+			s.data = append(s.data, strings.Join(lines, "\n"))
+			s.lastId++
+			id := s.lastId
+			autoEnd := s.autoEnd
+			s.mu.Unlock()
+			write(fmt.Sprintf("225-%d", id), "225 OK MESSAGE QUEUED")
+			if autoEnd {
+				write(fmt.Sprintf("702-%d", id), "702-1", "702 END")
+			}
+		case cmd == "CANCEL self":
+			s.mu.Lock()
+			id := s.lastId
+			s.mu.Unlock()
+			write("210 OK CANCELED")
+			write(fmt.Sprintf("703-%d", id), "703-1", "703 CANCELED")
+		case cmd == "QUIT":
+			write("231 HAPPY HACKING")
+			return
+		default:
+			write("300 ERR UNKNOWN COMMAND")
+		}
+	}
+}
+
+func (s *fakeServer) received() ([]string, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.commands...), append([]string{}, s.data...)
+}
+
+func newTestSynthesizer(t *testing.T) *Synthesizer {
+	t.Helper()
+	synth, err := NewSynthesizer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(synth.Release)
+	return synth.(*Synthesizer)
+}
+
+func TestSpeakSequence(t *testing.T) {
+	server := newFakeServer(t, true)
+	synth := newTestSynthesizer(t)
+	completed := make(chan int, 1)
+	synth.SetCompletionHandler(func(phraseId int) { completed <- phraseId })
+	phrase := &tts.Phrase{Id: 7, Text: "hello\n.world", Priority: tts.UiPriority}
+	if err := synth.Speak(phrase); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case id := <-completed:
+		if id != 7 {
+			t.Errorf("completed phrase %d, want 7", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the end of the phrase was not reported")
+	}
+	commands, data := server.received()
+	want := []string{
+		"SET self CLIENT_NAME user:toby_launcher:main",
+		"SET self NOTIFICATION END on",
+		"SET self NOTIFICATION CANCEL on",
+		"SET self PRIORITY message",
+		"SPEAK",
+	}
+	if strings.Join(commands, "|") != strings.Join(want, "|") {
+		t.Errorf("commands = %q, want %q", commands, want)
+	}
+	// The line starting with a dot is sent with the dot doubled.
+	if len(data) != 1 || data[0] != "hello\n..world" {
+		t.Errorf("data = %q", data)
+	}
+	if speaking, _ := synth.IsSpeaking(); speaking {
+		t.Error("the synthesizer is speaking after the end event")
+	}
+}
+
+func TestSettingsAreSentOnce(t *testing.T) {
+	server := newFakeServer(t, true)
+	synth := newTestSynthesizer(t)
+	synth.SetPitch(tts.MaxPitch)
+	for id := 1; id <= 2; id++ {
+		if err := synth.Speak(&tts.Phrase{Id: id, Text: "text", Priority: tts.NormalPriority}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commands, _ := server.received()
+	count := 0
+	for _, cmd := range commands {
+		if cmd == "SET self PITCH 100" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("the pitch was sent %d times in %q", count, commands)
+	}
+}
+
+func TestStopCancelsSpeech(t *testing.T) {
+	server := newFakeServer(t, false)
+	synth := newTestSynthesizer(t)
+	completed := make(chan int, 1)
+	synth.SetCompletionHandler(func(phraseId int) { completed <- phraseId })
+	if err := synth.Speak(&tts.Phrase{Id: 3, Text: "long text", Priority: tts.NormalPriority}); err != nil {
+		t.Fatal(err)
+	}
+	if speaking, _ := synth.IsSpeaking(); !speaking {
+		t.Fatal("the synthesizer is not speaking the queued message")
+	}
+	if err := synth.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if speaking, _ := synth.IsSpeaking(); speaking {
+		t.Error("the synthesizer is speaking after the stop")
+	}
+	select {
+	case id := <-completed:
+		if id != 3 {
+			t.Errorf("completed phrase %d, want 3", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the end of the canceled phrase was not reported")
+	}
+	commands, _ := server.received()
+	if commands[len(commands)-1] != "CANCEL self" {
+		t.Errorf("last command = %q, want CANCEL self", commands[len(commands)-1])
+	}
+	// Nothing is canceled when nothing is spoken.
+	if err := synth.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := server.received(); len(after) != len(commands) {
+		t.Errorf("stopping the silent synthesizer sent %q", after[len(commands):])
+	}
+}
+
+func TestServerErrorIsReported(t *testing.T) {
+	newFakeServer(t, true)
+	synth := newTestSynthesizer(t)
+	if _, err := synth.client.command("UNKNOWN"); err == nil {
+		t.Error("the error reply was not reported")
+	}
+}
@@ -0,0 +1,407 @@
+//go:build linux
+
+package speechd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"toby_launcher/core/tts"
+)
+
+func init() {
+	tts.RegisterSynthesizer(engineName, NewSynthesizer, 0)
+}
+
+const engineName = "speech dispatcher"
+
+// rateScale maps words per minute to the SSIP rates from -100 to 100.
+var rateScale = tts.RateScale{
+	MinWpm:     80,
+	NormalWpm:  175,
+	MaxWpm:     450,
+	MinRate:    -100,
+	NormalRate: 0,
+	MaxRate:    100,
+}
+
+// Synthesizer speaks through Speech Dispatcher, so the speech settings of the desktop apply
+// and the speech is arbitrated with screen readers such as Orca.
+// Zero values of the settings leave the settings of Speech Dispatcher unchanged.
+type Synthesizer struct {
+	tts.BaseSynthesizer
+	mu         sync.Mutex
+	client     *client
+	speechRate int
+	voice      string
+	pitch      int
+	volume     int
+	// sent holds the values of the settings sent over the current connection.
+	sent map[string]string
+	// stateMu guards the ids, which are updated by the events of the reading goroutine.
+	stateMu     sync.Mutex
+	currentId   int
+	lastEndedId int
+	// phraseId is the id of the phrase of the current message.
+	phraseId   int
+	onComplete func(phraseId int)
+}
+
+func NewSynthesizer() (tts.SpeechSynthesizer, error) {
+	synth := &Synthesizer{}
+	if err := synth.connect(); err != nil {
+		return nil, err
+	}
+	return synth, nil
+}
+
+func (s *Synthesizer) connect() error {
+	path, err := socketPath()
+	if err != nil {
+		return err
+	}
+	c, err := dial(path, s.handleEvent)
+	if err != nil {
+		return err
+	}
+	for _, cmd := range []string{
+		"SET self CLIENT_NAME user:toby_launcher:main",
+		"SET self NOTIFICATION END on",
+		"SET self NOTIFICATION CANCEL on",
+	} {
+		if _, err := c.command(cmd); err != nil {
+			c.close()
+			return err
+		}
+	}
+	s.client = c
+	s.sent = make(map[string]string)
+	s.stateMu.Lock()
+	s.lastEndedId = s.currentId
+	s.stateMu.Unlock()
+	return nil
+}
+
+// handleEvent marks the message as finished. Message ids increase, so every message up to
+// the finished one is finished too, even if its event arrives before its id is known.
+func (s *Synthesizer) handleEvent(code, msgId int) {
+	if code != codeEventEnd && code != codeEventCanceled {
+		return
+	}
+	s.stateMu.Lock()
+	if msgId > s.lastEndedId {
+		s.lastEndedId = msgId
+	}
+	s.stateMu.Unlock()
+	s.reportCompletion()
+}
+
+// reportCompletion reports the end of the current phrase once its message has finished.
+func (s *Synthesizer) reportCompletion() {
+	s.stateMu.Lock()
+	phraseId := s.phraseId
+	isFinished := phraseId != 0 && s.currentId <= s.lastEndedId
+	if isFinished {
+		s.phraseId = 0
+	}
+	onComplete := s.onComplete
+	s.stateMu.Unlock()
+	if isFinished && onComplete != nil {
+		onComplete(phraseId)
+	}
+}
+
+func (s *Synthesizer) SetCompletionHandler(f func(phraseId int)) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.onComplete = f
+}
+
+func (s *Synthesizer) Name() string {
+	return engineName
+}
+
+func (s *Synthesizer) CreateNew() (tts.SpeechSynthesizer, error) {
+	return NewSynthesizer()
+}
+
+func (s *Synthesizer) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil {
+		return
+	}
+	if err := s.stop(); err != nil {
+		s.LogError(err)
+	}
+	s.client.close()
+	s.client = nil
+}
+
+func (s *Synthesizer) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stop()
+}
+
+func (s *Synthesizer) stop() error {
+	if s.client == nil || s.client.isClosed() || !s.isSpeaking() {
+		return nil
+	}
+	if _, err := s.client.command("CANCEL self"); err != nil {
+		return err
+	}
+	s.stateMu.Lock()
+	s.lastEndedId = s.currentId
+	s.stateMu.Unlock()
+	return nil
+}
+
+func (s *Synthesizer) isSpeaking() bool {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.currentId > s.lastEndedId
+}
+
+func (s *Synthesizer) IsSpeaking() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil || s.client.isClosed() {
+		return false, nil
+	}
+	return s.isSpeaking(), nil
+}
+
+func (s *Synthesizer) Speak(phrase *tts.Phrase) error {
+	if phrase.Text == "" {
+		return fmt.Errorf("no text to speak has been specified")
+	}
+	if phrase.Silence > 0 {
+		phraseCopy := *phrase
+		go func(phrase *tts.Phrase) {
+			time.Sleep(time.Duration(phrase.Silence) * time.Millisecond)
+			phrase.Silence = 0
+			if err := s.Speak(phrase); err != nil {
+				s.LogError(err)
+			}
+		}(&phraseCopy)
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Speech Dispatcher may have been restarted since the last phrase.
+	if s.client == nil || s.client.isClosed() {
+		if s.client != nil {
+			s.client.close()
+			s.client = nil
+		}
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	if err := s.stop(); err != nil {
+		return err
+	}
+	if err := s.applyPhraseSettings(phrase); err != nil {
+		return err
+	}
+	text := phrase.Text
+	if phrase.Markup {
+		text = "<speak>" + text + "</speak>"
+	}
+	msgId, err := s.client.speak(text)
+	if err != nil {
+		return err
+	}
+	s.stateMu.Lock()
+	s.currentId = msgId
+	s.phraseId = phrase.Id
+	s.stateMu.Unlock()
+	// The message may have ended before its id was received.
+	s.reportCompletion()
+	return nil
+}
+
+// applyPhraseSettings sends the settings of the phrase, falling back to the synthesizer settings.
+// A setting that was changed before and now has the zero value is restored to the normal value.
+func (s *Synthesizer) applyPhraseSettings(phrase *tts.Phrase) error {
+	rate := phrase.Rate
+	if rate == 0 {
+		rate = s.speechRate
+	}
+	voice := phrase.Voice
+	if voice == "" {
+		voice = s.voice
+	}
+	pitch := phrase.Pitch
+	if pitch == 0 {
+		pitch = s.pitch
+	}
+	volume := phrase.Volume
+	if volume == 0 {
+		volume = s.volume
+	}
+	settings := []struct {
+		name    string
+		value   string
+		isSet   bool
+		neutral string
+	}{
+		{"PRIORITY", ssipPriority(phrase.Priority), true, ""},
+		{"RATE", strconv.Itoa(int(math.Round(rateScale.FromWpm(rate)))), rate > 0, "0"},
+		{"SYNTHESIS_VOICE", voice, voice != "", ""},
+		// The pitch 50 is the normal pitch, the volume 100 is the loudest one as in Speech Dispatcher.
+		{"PITCH", strconv.Itoa(scale(pitch, 0, tts.MaxPitch/2, tts.MaxPitch)), pitch > 0, "0"},
+		{"VOLUME", strconv.Itoa(scale(volume, 0, tts.MaxVolume/2, tts.MaxVolume)), volume > 0, "100"},
+		{"SSML_MODE", "on", phrase.Markup, "off"},
+	}
+	for _, setting := range settings {
+		value := setting.value
+		if !setting.isSet {
+			if _, changed := s.sent[setting.name]; !changed || setting.neutral == "" {
+				continue
+			}
+			value = setting.neutral
+		}
+		if s.sent[setting.name] == value {
+			continue
+		}
+		if _, err := s.client.command(fmt.Sprintf("SET self %s %s", setting.name, value)); err != nil {
+			return err
+		}
+		if setting.isSet {
+			s.sent[setting.name] = value
+		} else {
+			delete(s.sent, setting.name)
+		}
+	}
+	return nil
+}
+
+// ssipPriority maps the priority of a phrase to the SSIP priority. Notifications are dropped
+// by Speech Dispatcher while anything else is spoken, as chatter is dropped by the speech queue.
+func ssipPriority(priority tts.Priority) string {
+	switch priority {
+	case tts.ChatterPriority:
+		return "notification"
+	case tts.NormalPriority:
+		return "text"
+	default:
+		return "message"
+	}
+}
+
+// scale maps the value from the range low..normal..high to the range -100..0..100.
+func scale(value, low, normal, high int) int {
+	if value <= normal {
+		return max(-100, (value-normal)*100/(normal-low))
+	}
+	return min(100, (value-normal)*100/(high-normal))
+}
+
+func (s *Synthesizer) RateScale() tts.RateScale {
+	return rateScale
+}
+
+func (s *Synthesizer) Capabilities() tts.Capabilities {
+	return tts.RateCapability | tts.VoiceCapability | tts.PitchCapability | tts.VolumeCapability | tts.MarkupCapability
+}
+
+func (s *Synthesizer) SetSpeechRate(rate int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.speechRate = rate
+	return nil
+}
+
+func (s *Synthesizer) GetSpeechRate() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.speechRate
+}
+
+func (s *Synthesizer) SetVoice(voice string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.voice = voice
+	return nil
+}
+
+func (s *Synthesizer) GetVoice() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.voice
+}
+
+// Voices lists the voices of the current output module. Every voice is sent as
+// a line with the name, the language and the variant separated by tabs.
+func (s *Synthesizer) Voices() ([]tts.Voice, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil || s.client.isClosed() {
+		return nil, fmt.Errorf("speech dispatcher is not connected")
+	}
+	r, err := s.client.command("LIST SYNTHESIS_VOICES")
+	if err != nil {
+		return nil, err
+	}
+	voices := make([]tts.Voice, 0, len(r.lines))
+	for _, line := range r.lines {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+		gender := ""
+		if len(fields) > 2 {
+			variant := strings.ToUpper(fields[2])
+			switch {
+			case strings.Contains(variant, "FEMALE"):
+				gender = "female"
+			case strings.Contains(variant, "MALE"):
+				gender = "male"
+			}
+		}
+		voices = append(voices, tts.Voice{
+			Id:       fields[0],
+			Name:     fields[0],
+			Language: fields[1],
+			Gender:   gender,
+		})
+	}
+	return voices, nil
+}
+
+func (s *Synthesizer) SetPitch(pitch int) error {
+	if pitch < 0 || pitch > tts.MaxPitch {
+		return fmt.Errorf("pitch must be from 0 to %d", tts.MaxPitch)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pitch = pitch
+	return nil
+}
+
+func (s *Synthesizer) GetPitch() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pitch
+}
+
+func (s *Synthesizer) SetVolume(volume int) error {
+	if volume < 0 || volume > tts.MaxVolume {
+		return fmt.Errorf("volume must be from 0 to %d", tts.MaxVolume)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.volume = volume
+	return nil
+}
+
+func (s *Synthesizer) GetVolume() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.volume
+}
@@ -0,0 +1,28 @@
+package text
+
+import (
+	"fmt"
+	"toby_launcher/core/tts"
+)
+
+// consoleOutput prints the phrases to the launcher console.
+type consoleOutput struct {
+	prefix string
+}
+
+func newConsoleSynthesizer() (tts.SpeechSynthesizer, error) {
+	return newSynthesizer("console", newConsoleOutput)
+}
+
+func newConsoleOutput() (output, error) {
+	if console == nil {
+		return nil, fmt.Errorf("the launcher console is not available")
+	}
+	return &consoleOutput{prefix: settings.ConsolePrefix}, nil
+}
+
+func (o *consoleOutput) write(line string) error {
+	return console.Write(o.prefix + line + "\r\n")
+}
+
+func (o *consoleOutput) close() {}
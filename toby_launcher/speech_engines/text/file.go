@@ -0,0 +1,106 @@
+package text
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"toby_launcher/core/tts"
+)
+
+// fileQueueLength is the number of lines waiting to be written, above which lines are dropped.
+const fileQueueLength = 100
+
+// fileOutput appends the phrases to a file or a named pipe. The lines are written in a separate
+// goroutine, as writing to a named pipe waits until its reader reads the previous lines.
+// A named pipe is opened without waiting for a reader, the lines written while nobody reads it are dropped.
+type fileOutput struct {
+	lines chan string
+	done  chan struct{}
+	// mu guards the file, which is closed to interrupt a write waiting for the reader.
+	mu     sync.Mutex
+	file   *os.File
+	closed bool
+}
+
+func newFileSynthesizer() (tts.SpeechSynthesizer, error) {
+	return newSynthesizer("file", newFileOutput)
+}
+
+func newFileOutput() (output, error) {
+	if settings.File == "" {
+		return nil, fmt.Errorf("the file of the text output is not configured")
+	}
+	o := &fileOutput{
+		lines: make(chan string, fileQueueLength),
+		done:  make(chan struct{}),
+	}
+	go o.run(settings.File)
+	return o, nil
+}
+
+func (o *fileOutput) run(path string) {
+	for {
+		select {
+		case <-o.done:
+			return
+		case line := <-o.lines:
+			file := o.open(path)
+			if file == nil {
+				continue
+			}
+			if _, err := file.WriteString(line + "\n"); err != nil {
+				// The reader of a named pipe may have gone, the file is opened again for the next line.
+				o.closeFile(file)
+			}
+		}
+	}
+}
+
+// open returns the opened file, opening it if needed. It returns nil if the file cannot be opened,
+// such as a named pipe without a reader, or if the output is closed.
+func (o *fileOutput) open(path string) *os.File {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil
+	}
+	if o.file == nil {
+		// Without O_NONBLOCK, opening a named pipe would wait for a reader, and the output could not be closed.
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE|syscall.O_NONBLOCK, 0644)
+		if err != nil {
+			return nil
+		}
+		o.file = f
+	}
+	return o.file
+}
+
+func (o *fileOutput) closeFile(file *os.File) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.file == file {
+		o.file = nil
+	}
+	file.Close()
+}
+
+func (o *fileOutput) write(line string) error {
+	select {
+	case o.lines <- line:
+		return nil
+	default:
+		return fmt.Errorf("the text output file is not being read")
+	}
+}
+
+func (o *fileOutput) close() {
+	close(o.done)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.closed = true
+	if o.file != nil {
+		o.file.Close()
+		o.file = nil
+	}
+}
@@ -0,0 +1,64 @@
+//go:build !windows
+
+package text
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+	"toby_launcher/config"
+)
+
+func TestFifoWithoutReaderDoesNotBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "speech.fifo")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Skip("named pipes are not supported:", err)
+	}
+	configure(t, config.TextOutput{File: path}, nil)
+	goroutines := runtime.NumGoroutine()
+	out, err := newFileOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nobody reads the pipe, so the line is dropped.
+	if err := out.write("unread"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	reader, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if err := out.write("read"); err != nil {
+		t.Fatal(err)
+	}
+	// The read returns EOF until the output opens the pipe.
+	reader.SetReadDeadline(time.Now().Add(time.Second))
+	lines := bufio.NewReader(reader)
+	line, err := lines.ReadString('\n')
+	for err == io.EOF && line == "" {
+		time.Sleep(10 * time.Millisecond)
+		line, err = lines.ReadString('\n')
+	}
+	if err != nil || line != "read\n" {
+		t.Errorf("line = %q, %v, want the line written while the pipe is read", line, err)
+	}
+	reader.Close()
+	if err := out.write("gone"); err != nil {
+		t.Fatal(err)
+	}
+	out.close()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutines {
+		if time.Now().After(deadline) {
+			t.Fatal("the goroutine writing to the pipe has not exited")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
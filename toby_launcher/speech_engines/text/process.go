@@ -0,0 +1,71 @@
+package text
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"toby_launcher/core/tts"
+)
+
+// processOutput writes the phrases to the standard input of a separate process,
+// which is started again if it has exited.
+type processOutput struct {
+	command []string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+}
+
+func newProcessSynthesizer() (tts.SpeechSynthesizer, error) {
+	return newSynthesizer("process", newProcessOutput)
+}
+
+func newProcessOutput() (output, error) {
+	if len(settings.Command) == 0 {
+		return nil, fmt.Errorf("the command of the text output is not configured")
+	}
+	if _, err := exec.LookPath(settings.Command[0]); err != nil {
+		return nil, err
+	}
+	return &processOutput{command: settings.Command}, nil
+}
+
+func (o *processOutput) start() error {
+	cmd := exec.Command(o.command[0], o.command[1:]...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	o.cmd = cmd
+	o.stdin = stdin
+	go cmd.Wait()
+	return nil
+}
+
+func (o *processOutput) write(line string) error {
+	if o.cmd == nil {
+		if err := o.start(); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(o.stdin, line+"\n"); err != nil {
+		o.close()
+		if err := o.start(); err != nil {
+			return err
+		}
+		_, err = io.WriteString(o.stdin, line+"\n")
+		return err
+	}
+	return nil
+}
+
+func (o *processOutput) close() {
+	if o.cmd == nil {
+		return
+	}
+	o.stdin.Close()
+	o.cmd = nil
+	o.stdin = nil
+}
@@ -0,0 +1,159 @@
+package text
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"toby_launcher/config"
+	"toby_launcher/core/tts"
+)
+
+func init() {
+	tts.RegisterOutput("console", func() (tts.Output, error) { return newOutput("console", newConsoleOutput) })
+	tts.RegisterOutput("file", func() (tts.Output, error) { return newOutput("file", newFileOutput) })
+	tts.RegisterOutput("process", func() (tts.Output, error) { return newOutput("process", newProcessOutput) })
+}
+
+// Console is the launcher console the console synthesizer and output print to.
+type Console interface {
+	Write(s string) error
+}
+
+var (
+	settings config.TextOutput
+	console  Console
+)
+
+// Register sets the prefix, the file and the command of the text synthesizers and registers
+// the ones selected in the configuration or listed in the engine order. The text synthesizers
+// are silent, so they are never selected automatically when no speech engine is available.
+// It must be called before the speech synthesizers are initialized.
+func Register(cfg *config.TtsConfig, launcherConsole Console) {
+	settings = cfg.TextOutput
+	console = launcherConsole
+	factories := map[string]func() (tts.SpeechSynthesizer, error){
+		"console": newConsoleSynthesizer,
+		"file":    newFileSynthesizer,
+		"process": newProcessSynthesizer,
+	}
+	for _, name := range []string{"console", "file", "process"} {
+		if name == cfg.SynthesizerName || slices.Contains(cfg.EngineOrder, name) {
+			tts.RegisterExplicitSynthesizer(name, factories[name], 3)
+		}
+	}
+}
+
+// output writes the phrases somewhere they can be read.
+type output interface {
+	write(line string) error
+	close()
+}
+
+// Synthesizer writes phrases instead of speaking them, for braille displays and screen readers
+// watching the terminal. It needs no audio, and a phrase is finished as soon as it is written.
+type Synthesizer struct {
+	tts.BaseSynthesizer
+	mu     sync.Mutex
+	name   string
+	create func() (output, error)
+	output output
+}
+
+func newSynthesizer(name string, create func() (output, error)) (tts.SpeechSynthesizer, error) {
+	out, err := create()
+	if err != nil {
+		return nil, err
+	}
+	return &Synthesizer{
+		name:   name,
+		create: create,
+		output: out,
+	}, nil
+}
+
+func (s *Synthesizer) Name() string {
+	return s.name
+}
+
+func (s *Synthesizer) CreateNew() (tts.SpeechSynthesizer, error) {
+	return newSynthesizer(s.name, s.create)
+}
+
+func (s *Synthesizer) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.output != nil {
+		s.output.close()
+		s.output = nil
+	}
+}
+
+// Speak writes the text of the phrase as one line. Silence is not written.
+func (s *Synthesizer) Speak(phrase *tts.Phrase) error {
+	if phrase.Text == "" {
+		return fmt.Errorf("no text to speak has been specified")
+	}
+	line := toLine(phrase.Text)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.output == nil {
+		return fmt.Errorf("the %s synthesizer has been released", s.name)
+	}
+	return s.output.write(line)
+}
+
+func (s *Synthesizer) Stop() error {
+	return nil
+}
+
+func (s *Synthesizer) IsSpeaking() (bool, error) {
+	return false, nil
+}
+
+func (s *Synthesizer) Capabilities() tts.Capabilities {
+	return 0
+}
+
+func (s *Synthesizer) SetSpeechRate(rate int) error {
+	return fmt.Errorf("the %s synthesizer writes text and has no speech rate", s.name)
+}
+
+func (s *Synthesizer) GetSpeechRate() int {
+	return 0
+}
+
+func toLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Output writes the phrases in addition to the speech synthesizer, such as to a text log.
+type Output struct {
+	mu     sync.Mutex
+	name   string
+	output output
+}
+
+func newOutput(name string, create func() (output, error)) (tts.Output, error) {
+	out, err := create()
+	if err != nil {
+		return nil, err
+	}
+	return &Output{name: name, output: out}, nil
+}
+
+func (o *Output) Name() string {
+	return o.name
+}
+
+func (o *Output) Write(text string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.output.write(toLine(text))
+}
+
+func (o *Output) Release() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.output.close()
+}
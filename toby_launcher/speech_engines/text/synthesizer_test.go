@@ -0,0 +1,131 @@
+package text
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"toby_launcher/config"
+	"toby_launcher/core/tts"
+)
+
+// fakeConsole records what is written to the launcher console.
+type fakeConsole struct {
+	written []string
+}
+
+func (c *fakeConsole) Write(s string) error {
+	c.written = append(c.written, s)
+	return nil
+}
+
+// configure sets the text output settings and the console for the test.
+func configure(t *testing.T, textOutput config.TextOutput, launcherConsole Console) {
+	t.Helper()
+	previousSettings, previousConsole := settings, console
+	settings, console = textOutput, launcherConsole
+	t.Cleanup(func() { settings, console = previousSettings, previousConsole })
+}
+
+func speak(t *testing.T, synth tts.SpeechSynthesizer, texts ...string) {
+	t.Helper()
+	for i, text := range texts {
+		if err := synth.Speak(&tts.Phrase{Id: i + 1, Text: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// waitContent waits until the file has the content.
+func waitContent(t *testing.T, path string, want string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	var content []byte
+	for time.Now().Before(deadline) {
+		content, _ = os.ReadFile(path)
+		if string(content) == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("content = %q, want %q", content, want)
+}
+
+func TestConsoleWritesPrefixedLines(t *testing.T) {
+	c := &fakeConsole{}
+	configure(t, config.TextOutput{ConsolePrefix: "tts: "}, c)
+	synth, err := newConsoleSynthesizer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer synth.Release()
+	speak(t, synth, "You got the\r\nshotgun!", "  Door  ")
+	want := []string{"tts: You got the shotgun!\r\n", "tts: Door\r\n"}
+	if strings.Join(c.written, "|") != strings.Join(want, "|") {
+		t.Errorf("written = %q, want %q", c.written, want)
+	}
+	if err := synth.Speak(&tts.Phrase{Id: 3}); err == nil {
+		t.Error("silence was written")
+	}
+}
+
+func TestConsoleIsRequired(t *testing.T) {
+	configure(t, config.TextOutput{}, nil)
+	if _, err := newConsoleSynthesizer(); err == nil {
+		t.Error("the console synthesizer was created without the console")
+	}
+}
+
+func TestFileAppendsLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "speech.txt")
+	if err := os.WriteFile(path, []byte("earlier\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configure(t, config.TextOutput{File: path}, nil)
+	synth, err := newFileSynthesizer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer synth.Release()
+	speak(t, synth, "Picked up\tthe armor.", "E1M1")
+	waitContent(t, path, "earlier\nPicked up the armor.\nE1M1\n")
+}
+
+func TestFileIsRequired(t *testing.T) {
+	configure(t, config.TextOutput{}, nil)
+	if _, err := newFileSynthesizer(); err == nil {
+		t.Error("the file synthesizer was created without the file")
+	}
+}
+
+func TestProcessReceivesLines(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+	path := filepath.Join(t.TempDir(), "speech.txt")
+	configure(t, config.TextOutput{Command: []string{"sh", "-c", `cat >> "$0"`, path}}, nil)
+	synth, err := newProcessSynthesizer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	speak(t, synth, "Health\n100", "Armor 50")
+	waitContent(t, path, "Health 100\nArmor 50\n")
+	synth.Release()
+	// The process exits when its standard input is closed, so a new one is started for the next synthesizer.
+	synth, err = synth.CreateNew()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer synth.Release()
+	speak(t, synth, "Ammo 20")
+	waitContent(t, path, "Health 100\nArmor 50\nAmmo 20\n")
+}
+
+func TestProcessCommandMustExist(t *testing.T) {
+	configure(t, config.TextOutput{Command: []string{"toby-missing-reader"}}, nil)
+	if _, err := newProcessSynthesizer(); err == nil {
+		t.Error("the process synthesizer was created without the command")
+	}
+}